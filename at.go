@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// overlap is an incident whose lifetime overlaps the queried time, with the
+// state it was in at that moment.
+type overlap struct {
+	Timeline   incidentTimeline
+	Start      time.Time
+	Moment     time.Time
+	Status     string
+	Components []componentState
+	Health     health
+}
+
+type componentState struct {
+	Name   string
+	Status string
+}
+
+// findOverlaps returns the incidents whose lifetime, from the first update
+// to the resolution, overlaps window and that affect a component selected
+// by filter, ordered by start. For a range the state is taken at the last
+// overlapping moment.
+func findOverlaps(incidents []incident, window timeWindow, filter componentFilter, groups componentGroups, now time.Time) []overlap {
+	var out []overlap
+	for _, inc := range incidents {
+		if !incidentSelected(inc, filter, groups) {
+			continue
+		}
+
+		tl := buildTimeline(inc, now)
+		start := tl.Start
+		if len(tl.Entries) > 0 && !tl.Entries[0].At.IsZero() {
+			start = tl.Entries[0].At
+		}
+		if start.IsZero() {
+			start = incidentTime(inc)
+		}
+		if start.IsZero() || start.After(window.Until) || tl.End.Before(window.Since) {
+			continue
+		}
+
+		moment := window.Until
+		if tl.End.Before(moment) {
+			moment = tl.End
+		}
+		out = append(out, stateAt(tl, start, moment))
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start.Before(out[j].Start)
+	})
+	return out
+}
+
+// stateAt replays the timeline up to moment to find the incident status and
+// the status of each affected component.
+func stateAt(tl incidentTimeline, start, moment time.Time) overlap {
+	o := overlap{Timeline: tl, Start: start, Moment: moment, Health: impactHealth(tl.Incident.Impact)}
+
+	statuses := make(map[string]string)
+	var order []string
+	note := func(name, status string) {
+		if _, ok := statuses[name]; !ok {
+			order = append(order, name)
+		}
+		statuses[name] = status
+	}
+	for _, name := range incidentComponentNames(tl.Incident) {
+		note(name, "")
+	}
+
+	for _, entry := range tl.Entries {
+		if entry.At.After(moment) {
+			// A component first changed after the moment was still in its
+			// old status then.
+			for _, change := range entry.Update.AffectedComponents {
+				if statuses[change.Name] == "" {
+					note(change.Name, change.OldStatus)
+				}
+			}
+			continue
+		}
+		o.Status = entry.Update.Status
+		for _, change := range entry.Update.AffectedComponents {
+			note(change.Name, change.NewStatus)
+		}
+	}
+	if o.Status == "" {
+		o.Status = tl.Incident.Status
+	}
+
+	for _, name := range order {
+		o.Components = append(o.Components, componentState{Name: name, Status: statuses[name]})
+		o.Health = max(o.Health, componentHealth(statuses[name]))
+	}
+	return o
+}
+
+func describeMoment(window timeWindow) string {
+	if window.Since.Equal(window.Until) {
+		return window.Since.Local().Format("Jan 02 2006 15:04")
+	}
+	return fmt.Sprintf("%s and %s", window.Since.Local().Format("Jan 02 2006 15:04"), window.Until.Local().Format("Jan 02 2006 15:04"))
+}
+
+func renderOverlapsText(w io.Writer, overlaps []overlap, window timeWindow, st style) {
+	when := "at " + describeMoment(window)
+	if !window.Since.Equal(window.Until) {
+		when = "between " + describeMoment(window)
+	}
+	if len(overlaps) == 0 {
+		fmt.Fprintf(w, "No incidents %s (local time).\n", when)
+		return
+	}
+
+	fmt.Fprintf(w, "Incidents %s (local time):\n", when)
+	for _, o := range overlaps {
+		inc := o.Timeline.Incident
+		fmt.Fprintf(w, "\n%s%s\n", st.icon(inc.Impact), inc.Name)
+		fmt.Fprintf(w, "  ID: %s\n", inc.ID)
+		if impact := st.status(inc.Impact); impact != "" {
+			fmt.Fprintf(w, "  Impact: %s\n", impact)
+		}
+		end := "ongoing"
+		if !o.Timeline.Ongoing {
+			end = o.Timeline.End.Local().Format("Jan 02 15:04")
+		}
+		fmt.Fprintf(w, "  Lifetime: %s → %s\n", o.Start.Local().Format("Jan 02 15:04"), end)
+		fmt.Fprintf(w, "  Status at %s: %s\n", o.Moment.Local().Format("Jan 02 15:04"), st.status(o.Status))
+		for _, comp := range o.Components {
+			status := "Affected"
+			if comp.Status != "" {
+				status = st.status(comp.Status)
+			}
+			fmt.Fprintf(w, "    %s%s - %s\n", st.icon(comp.Status), comp.Name, status)
+		}
+		if inc.Shortlink != "" {
+			fmt.Fprintf(w, "  More info: %s\n", inc.Shortlink)
+		}
+	}
+}
+
+// overlapsHealth is the worst state any overlapping incident was in.
+func overlapsHealth(overlaps []overlap) health {
+	worst := healthOperational
+	for _, o := range overlaps {
+		worst = max(worst, o.Health)
+	}
+	return worst
+}
+
+func renderOverlapsJSON(w io.Writer, statusPage string, overlaps []overlap, window timeWindow) error {
+	payload := jsonOverlaps{
+		StatusPage: statusPage,
+		Since:      window.Since.UTC().Format(time.RFC3339),
+		Until:      window.Until.UTC().Format(time.RFC3339),
+		Health:     overlapsHealth(overlaps).String(),
+		Incidents:  make([]jsonOverlap, 0, len(overlaps)),
+	}
+	for _, o := range overlaps {
+		inc := o.Timeline.Incident
+		item := jsonOverlap{
+			ID:         inc.ID,
+			Name:       inc.Name,
+			Impact:     strings.ToLower(strings.TrimSpace(inc.Impact)),
+			Shortlink:  inc.Shortlink,
+			StartedAt:  o.Start.UTC().Format(time.RFC3339),
+			Ongoing:    o.Timeline.Ongoing,
+			Moment:     o.Moment.UTC().Format(time.RFC3339),
+			Status:     strings.ToLower(strings.TrimSpace(o.Status)),
+			Health:     o.Health.String(),
+			Components: make([]jsonComponentState, 0, len(o.Components)),
+		}
+		if !o.Timeline.Ongoing {
+			item.ResolvedAt = o.Timeline.End.UTC().Format(time.RFC3339)
+		}
+		for _, comp := range o.Components {
+			item.Components = append(item.Components, jsonComponentState{Name: comp.Name, Status: comp.Status})
+		}
+		payload.Incidents = append(payload.Incidents, item)
+	}
+	return encodeJSON(w, payload)
+}
+
+type jsonOverlaps struct {
+	StatusPage string        `json:"status_page"`
+	Since      string        `json:"since"`
+	Until      string        `json:"until"`
+	Health     string        `json:"health"`
+	Incidents  []jsonOverlap `json:"incidents"`
+}
+
+type jsonOverlap struct {
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Impact     string               `json:"impact"`
+	Shortlink  string               `json:"shortlink,omitempty"`
+	StartedAt  string               `json:"started_at"`
+	ResolvedAt string               `json:"resolved_at,omitempty"`
+	Ongoing    bool                 `json:"ongoing"`
+	Moment     string               `json:"status_at"`
+	Status     string               `json:"status"`
+	Health     string               `json:"health"`
+	Components []jsonComponentState `json:"components"`
+}
+
+type jsonComponentState struct {
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpCache stores status page responses on disk so repeated runs can skip
+// the network while a response is fresh and revalidate it cheaply with
+// If-None-Match/If-Modified-Since once it is not.
+type httpCache struct {
+	dir string
+	// maxAge, when positive, overrides the freshness lifetime advertised by
+	// the server's Cache-Control header.
+	maxAge time.Duration
+}
+
+type cacheEntry struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	StoredAt     time.Time       `json:"stored_at"`
+	MaxAge       time.Duration   `json:"max_age"`
+	Body         json.RawMessage `json:"body"`
+}
+
+func newHTTPCache(dir string, maxAge time.Duration) *httpCache {
+	return &httpCache{dir: dir, maxAge: maxAge}
+}
+
+// defaultCacheDir returns the gh-down directory under the user cache dir.
+func defaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gh-down", "http"), nil
+}
+
+func (c *httpCache) path(url string) string {
+	return keyedPath(c.dir, url, ".json")
+}
+
+// load returns the cached entry for url, or nil when there is none or it
+// cannot be read.
+func (c *httpCache) load(url string) *cacheEntry {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url || len(entry.Body) == 0 {
+		return nil
+	}
+	return &entry
+}
+
+// store writes entry to disk. Failures are ignored: the cache is an
+// optimisation and must never break a run.
+func (c *httpCache) store(entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(c.path(entry.URL), data)
+}
+
+func (c *httpCache) fresh(entry *cacheEntry, now time.Time) bool {
+	lifetime := entry.MaxAge
+	if c.maxAge > 0 {
+		lifetime = c.maxAge
+	}
+	return lifetime > 0 && now.Sub(entry.StoredAt) < lifetime
+}
+
+// update records the validators and freshness lifetime from resp on entry.
+func (e *cacheEntry) update(resp *http.Response, now time.Time) {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		e.ETag = etag
+	}
+	if modified := resp.Header.Get("Last-Modified"); modified != "" {
+		e.LastModified = modified
+	}
+	e.StoredAt = now
+	e.MaxAge = cacheMaxAge(resp.Header.Get("Cache-Control"))
+}
+
+// cacheable reports whether the response may be written to the cache.
+func cacheable(resp *http.Response) bool {
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return false
+		}
+	}
+	return true
+}
+
+func cacheMaxAge(header string) time.Duration {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			return 0
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && secs > 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return maxAge
+}
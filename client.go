@@ -3,103 +3,511 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
-	componentsURL = "https://www.githubstatus.com/api/v2/components.json"
-	unresolvedURL = "https://www.githubstatus.com/api/v2/incidents/unresolved.json"
-	incidentsURL  = "https://www.githubstatus.com/api/v2/incidents.json"
-	userAgent     = "gh-down/" + version
+	summaryPath    = "/api/v2/summary.json"
+	componentsPath = "/api/v2/components.json"
+	unresolvedPath = "/api/v2/incidents/unresolved.json"
+	incidentsPath  = "/api/v2/incidents.json"
+	upcomingPath   = "/api/v2/scheduled-maintenances/upcoming.json"
+	inProgressPath = "/api/v2/scheduled-maintenances/active.json"
+	incidentPath   = "/api/v2/incidents/%s.json"
+	userAgent      = "gh-down/" + version
+
+	// maxHistoryPages bounds how far back ResolvedIncidents pages.
+	maxHistoryPages = 20
 )
 
 type statusClient struct {
 	http          *http.Client
+	retry         retryPolicy
+	cache         *httpCache
+	history       *historyStore
+	siteURL       string
+	summaryURL    string
 	componentsURL string
 	unresolvedURL string
 	incidentsURL  string
+	upcomingURL   string
+	inProgressURL string
 }
 
-func newStatusClient(timeout time.Duration) *statusClient {
+// newStatusClient returns a client for the Statuspage site at siteURL, which
+// must already be normalized by normalizeStatusPage.
+func newStatusClient(siteURL string, timeout time.Duration) *statusClient {
+	base := strings.TrimSuffix(siteURL, "/")
 	return &statusClient{
 		http: &http.Client{
 			Timeout: timeout,
 		},
-		componentsURL: componentsURL,
-		unresolvedURL: unresolvedURL,
-		incidentsURL:  incidentsURL,
+		retry:         defaultRetryPolicy,
+		siteURL:       siteURL,
+		summaryURL:    base + summaryPath,
+		componentsURL: base + componentsPath,
+		unresolvedURL: base + unresolvedPath,
+		incidentsURL:  base + incidentsPath,
+		upcomingURL:   base + upcomingPath,
+		inProgressURL: base + inProgressPath,
+	}
+}
+
+func (c *statusClient) Summary(ctx context.Context) (summaryResponse, error) {
+	var payload summaryResponse
+	changed, err := c.get(ctx, c.summaryURL, &payload)
+	if err != nil {
+		return summaryResponse{}, fmt.Errorf("fetch summary: %w", err)
 	}
+	if changed {
+		c.observe(payload.Incidents, payload.Components)
+	}
+	return payload, nil
 }
 
 func (c *statusClient) Components(ctx context.Context) ([]component, error) {
 	var payload statusResponse
-	if err := c.get(ctx, c.componentsURL, &payload); err != nil {
+	changed, err := c.get(ctx, c.componentsURL, &payload)
+	if err != nil {
 		return nil, fmt.Errorf("fetch components: %w", err)
 	}
+	if changed {
+		c.observe(nil, payload.Components)
+	}
 	return payload.Components, nil
 }
 
 func (c *statusClient) ActiveIncidents(ctx context.Context) ([]incident, error) {
 	var payload incidentResponse
-	if err := c.get(ctx, c.unresolvedURL, &payload); err != nil {
+	changed, err := c.get(ctx, c.unresolvedURL, &payload)
+	if err != nil {
 		return nil, fmt.Errorf("fetch active incidents: %w", err)
 	}
+	if changed {
+		c.observe(payload.Incidents, nil)
+	}
 	return payload.Incidents, nil
 }
 
-func (c *statusClient) RecentResolvedIncidents(ctx context.Context, lookback time.Duration) ([]incident, error) {
-	var payload incidentResponse
-	if err := c.get(ctx, c.incidentsURL, &payload); err != nil {
-		return nil, fmt.Errorf("fetch resolved incidents: %w", err)
-	}
-
-	cutoff := time.Now().Add(-lookback)
-	results := make([]incident, 0, len(payload.Incidents))
+// ResolvedIncidents returns the resolved incidents that fall within window.
+// It pages back through incidents.json until it passes window.Since, records
+// what it saw in the local history store and fills in anything older than
+// the status page still serves from that store.
+func (c *statusClient) ResolvedIncidents(ctx context.Context, window timeWindow) ([]incident, error) {
+	var (
+		fetched  []incident
+		observed []incident
+		oldest   time.Time
+	)
 	seen := make(map[string]struct{})
 
-	for _, inc := range payload.Incidents {
-		if !strings.EqualFold(inc.Status, "resolved") {
-			continue
+	for page := 1; page <= maxHistoryPages; page++ {
+		url := c.incidentsURL
+		if page > 1 {
+			url += "?page=" + strconv.Itoa(page)
 		}
 
-		if inc.ID != "" {
-			if _, found := seen[inc.ID]; found {
-				continue
+		var payload incidentResponse
+		changed, err := c.get(ctx, url, &payload)
+		if err != nil {
+			if page == 1 || ctx.Err() != nil {
+				return nil, fmt.Errorf("fetch resolved incidents: %w", err)
 			}
-			seen[inc.ID] = struct{}{}
+			// Older pages are best effort; many sites do not serve them.
+			break
 		}
 
-		if t := incidentTime(inc); !t.IsZero() && t.Before(cutoff) {
-			continue
+		added := 0
+		for _, inc := range payload.Incidents {
+			if inc.ID != "" {
+				if _, found := seen[inc.ID]; found {
+					continue
+				}
+				seen[inc.ID] = struct{}{}
+			}
+			added++
+			fetched = append(fetched, inc)
+			if changed {
+				observed = append(observed, inc)
+			}
+			if t := incidentTime(inc); !t.IsZero() && (oldest.IsZero() || t.Before(oldest)) {
+				oldest = t
+			}
 		}
 
+		// A page with nothing new means the site ignores ?page= or has run out.
+		if added == 0 || (!window.Since.IsZero() && !oldest.IsZero() && oldest.Before(window.Since)) {
+			break
+		}
+	}
+
+	c.observe(observed, nil)
+
+	candidates := fetched
+	if window.Since.IsZero() || oldest.IsZero() || window.Since.Before(oldest) {
+		stored, _ := c.history.incidents(c.siteURL)
+		for _, inc := range stored {
+			if _, found := seen[inc.ID]; !found {
+				candidates = append(candidates, inc)
+			}
+		}
+	}
+
+	results := make([]incident, 0, len(candidates))
+	for _, inc := range candidates {
+		if !strings.EqualFold(inc.Status, "resolved") {
+			continue
+		}
+		if t := incidentTime(inc); !t.IsZero() && !window.contains(t) {
+			continue
+		}
 		results = append(results, inc)
 	}
 
 	return results, nil
 }
 
-func (c *statusClient) get(ctx context.Context, url string, target interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// observe records what a response showed in the local history store. The
+// store is best effort and never fails a fetch. Responses served from the
+// HTTP cache were recorded when they were first fetched and are not passed
+// here again.
+func (c *statusClient) observe(incidents []incident, components []component) {
+	_ = c.history.record(c.siteURL, incidents, components, time.Now())
+}
+
+// Incident fetches a single incident with its full update history.
+func (c *statusClient) Incident(ctx context.Context, id string) (incident, error) {
+	var payload singleIncidentResponse
+	url := strings.TrimSuffix(c.siteURL, "/") + fmt.Sprintf(incidentPath, neturl.PathEscape(id))
+	changed, err := c.get(ctx, url, &payload)
+	if err != nil {
+		return incident{}, fmt.Errorf("fetch incident %s: %w", id, err)
+	}
+	if payload.Incident.ID == "" && payload.Incident.Name == "" {
+		return incident{}, fmt.Errorf("incident %s not found", id)
+	}
+	if changed {
+		c.observe([]incident{payload.Incident}, nil)
+	}
+	return payload.Incident, nil
+}
+
+// shortlinkHost serves the incident shortlinks of every Statuspage site.
+const shortlinkHost = "stspg.io"
+
+// ResolveIncidentID turns an incident ID, incident page URL or shortlink
+// such as https://stspg.io/abc123 into an incident ID. Shortlinks are
+// followed to the incident page they redirect to. URLs must point to the
+// client's status page, since the ID is looked up there.
+func (c *statusClient) ResolveIncidentID(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("incident ID must not be empty")
+	}
+	if !strings.Contains(ref, "/") {
+		return ref, nil
+	}
+
+	u, err := neturl.Parse(ref)
+	if err != nil || u.Host == "" {
+		if !strings.Contains(ref, "://") {
+			u, err = neturl.Parse("https://" + ref)
+		}
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid incident reference %q", ref)
+		}
+	}
+	if !strings.EqualFold(u.Hostname(), shortlinkHost) {
+		if err := c.checkIncidentHost(ref, u); err != nil {
+			return "", err
+		}
+	}
+	if id, ok := incidentIDFromPath(u.Path); ok {
+		return id, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	resp.Body.Close()
+
+	if err := c.checkIncidentHost(ref, resp.Request.URL); err != nil {
+		return "", err
+	}
+	if id, ok := incidentIDFromPath(resp.Request.URL.Path); ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("%s does not point to an incident", ref)
+}
+
+// checkIncidentHost reports an error unless u is on the client's status
+// page. A leading "www." is ignored on either side.
+func (c *statusClient) checkIncidentHost(ref string, u *neturl.URL) error {
+	site, err := neturl.Parse(c.siteURL)
 	if err != nil {
 		return err
 	}
+	trim := func(host string) string {
+		return strings.TrimPrefix(strings.ToLower(host), "www.")
+	}
+	if trim(u.Host) != trim(site.Host) {
+		return fmt.Errorf("%s belongs to %s, not %s; pass --status-page %s to look it up there", ref, u.Host, site.Host, u.Host)
+	}
+	return nil
+}
+
+func incidentIDFromPath(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "incidents" && parts[i+1] != "" {
+			return strings.TrimSuffix(parts[i+1], ".json"), true
+		}
+	}
+	return "", false
+}
+
+func (c *statusClient) UpcomingMaintenances(ctx context.Context) ([]maintenance, error) {
+	var payload maintenanceResponse
+	if _, err := c.get(ctx, c.upcomingURL, &payload); err != nil {
+		return nil, fmt.Errorf("fetch upcoming maintenances: %w", err)
+	}
+	return payload.ScheduledMaintenances, nil
+}
+
+func (c *statusClient) ActiveMaintenances(ctx context.Context) ([]maintenance, error) {
+	var payload maintenanceResponse
+	if _, err := c.get(ctx, c.inProgressURL, &payload); err != nil {
+		return nil, fmt.Errorf("fetch active maintenances: %w", err)
+	}
+	return payload.ScheduledMaintenances, nil
+}
+
+// get fetches url and decodes the JSON body into target, retrying transient
+// failures according to c.retry for as long as ctx allows. changed is false
+// when the body was served from the cache, whether it was still fresh or the
+// server answered 304 Not Modified.
+func (c *statusClient) get(ctx context.Context, url string, target interface{}) (changed bool, err error) {
+	for attempt := 1; ; attempt++ {
+		changed, err = c.fetch(ctx, url, target)
+		if err == nil || attempt >= c.retry.attempts || !retryable(ctx, err) {
+			return changed, err
+		}
+		if !sleepContext(ctx, c.retry.delay(attempt, err)) {
+			return changed, err
+		}
+	}
+}
+
+func (c *statusClient) fetch(ctx context.Context, url string, target interface{}) (bool, error) {
+	var entry *cacheEntry
+	if c.cache != nil {
+		entry = c.cache.load(url)
+		if entry != nil && c.cache.fresh(entry, time.Now()) {
+			return false, json.Unmarshal(entry.Body, target)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
 	req.Header.Set("User-Agent", userAgent)
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		entry.update(resp, time.Now())
+		c.cache.store(entry)
+		return false, json.Unmarshal(entry.Body, target)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected response: %s", resp.Status)
+		return false, &responseError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if c.cache == nil {
+		return true, json.NewDecoder(resp.Body).Decode(target)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return false, err
+	}
+	if cacheable(resp) {
+		fresh := &cacheEntry{URL: url, Body: body}
+		fresh.update(resp, time.Now())
+		c.cache.store(fresh)
+	}
+	return true, nil
+}
+
+// responseError reports a non-200 response from the status page.
+type responseError struct {
+	Status     string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("unexpected response: %s", e.Status)
+}
+
+// retryPolicy controls how statusClient retries failed GET requests.
+// attempts counts the initial request, so 1 disables retries.
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	attempts:  defaultRetries + 1,
+	baseDelay: 250 * time.Millisecond,
+	maxDelay:  5 * time.Second,
+}
+
+// delay returns how long to wait before the next attempt, preferring the
+// server's Retry-After over exponential backoff with jitter.
+func (p retryPolicy) delay(attempt int, err error) time.Duration {
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.RetryAfter > 0 {
+		return respErr.RetryAfter
+	}
+
+	backoff := p.baseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > p.maxDelay {
+		backoff = p.maxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var respErr *responseError
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooEarly,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// Transport failures surface as *url.Error; decode errors do not and are
+	// not worth repeating.
+	var urlErr *neturl.Error
+	return errors.As(err, &urlErr)
+}
+
+// networkError reports whether err means the status page could not be
+// reached at all, as opposed to answering with an error.
+func networkError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var urlErr *neturl.Error
+	return errors.As(err, &urlErr)
+}
+
+// sleepContext waits for d unless ctx is cancelled first or its deadline
+// would pass before d elapses. It reports whether the full wait happened.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func parseRetryAfter(raw string, now time.Time) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(raw); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
 	}
+	if t, err := http.ParseTime(raw); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
 
-	return json.NewDecoder(resp.Body).Decode(target)
+type summaryResponse struct {
+	Page                  page          `json:"page"`
+	Status                pageStatus    `json:"status"`
+	Components            []component   `json:"components"`
+	Incidents             []incident    `json:"incidents"`
+	ScheduledMaintenances []maintenance `json:"scheduled_maintenances"`
+}
+
+type page struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	TimeZone  string `json:"time_zone"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type pageStatus struct {
+	Indicator   string `json:"indicator"`
+	Description string `json:"description"`
 }
 
 type statusResponse struct {
@@ -110,10 +518,28 @@ type incidentResponse struct {
 	Incidents []incident `json:"incidents"`
 }
 
+type singleIncidentResponse struct {
+	Incident incident `json:"incident"`
+}
+
+type maintenanceResponse struct {
+	ScheduledMaintenances []maintenance `json:"scheduled_maintenances"`
+}
+
 type component struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	Group  bool   `json:"group"`
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Status             string   `json:"status"`
+	Description        string   `json:"description"`
+	Position           int      `json:"position"`
+	Group              bool     `json:"group"`
+	GroupID            string   `json:"group_id"`
+	Components         []string `json:"components"`
+	Showcase           bool     `json:"showcase"`
+	OnlyShowIfDegraded bool     `json:"only_show_if_degraded"`
+	StartDate          string   `json:"start_date"`
+	CreatedAt          string   `json:"created_at"`
+	UpdatedAt          string   `json:"updated_at"`
 }
 
 type incident struct {
@@ -124,13 +550,33 @@ type incident struct {
 	Shortlink       string           `json:"shortlink"`
 	CreatedAt       string           `json:"created_at"`
 	UpdatedAt       string           `json:"updated_at"`
+	StartedAt       string           `json:"started_at"`
+	ResolvedAt      string           `json:"resolved_at"`
+	Components      []component      `json:"components"`
 	IncidentUpdates []incidentUpdate `json:"incident_updates"`
 }
 
+type maintenance struct {
+	incident
+	ScheduledFor   string `json:"scheduled_for"`
+	ScheduledUntil string `json:"scheduled_until"`
+}
+
 type incidentUpdate struct {
-	Status    string `json:"status"`
-	Body      string `json:"body"`
-	CreatedAt string `json:"created_at"`
+	ID                 string              `json:"id"`
+	Status             string              `json:"status"`
+	Body               string              `json:"body"`
+	CreatedAt          string              `json:"created_at"`
+	AffectedComponents []affectedComponent `json:"affected_components"`
+}
+
+// affectedComponent records a component status change attached to an
+// incident update.
+type affectedComponent struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
 }
 
 func incidentTime(inc incident) time.Time {
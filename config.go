@@ -3,7 +3,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
 	"time"
+	"unicode"
+
+	"github.com/cli/go-gh/v2/pkg/term"
 )
 
 const (
@@ -12,49 +19,509 @@ const (
 	statusSiteURL      = "https://www.githubstatus.com/"
 	outputText         = "text"
 	outputJSON         = "json"
+	outputTemplate     = "template"
+	outputTable        = "table"
+	outputMarkdown     = "markdown"
+	sortByName         = "name"
+	sortByPosition     = "position"
 	referenceComponent = "Visit www.githubstatus.com for more information"
-	resolvedLookback   = 7 * 24 * time.Hour
+	defaultRetries     = 2
+	// statusPagesEnv lists the status pages to query when no --status-page
+	// is given, separated by commas or whitespace.
+	statusPagesEnv = "GH_DOWN_STATUS_PAGES"
 )
 
 type config struct {
-	showDetails  bool
-	showResolved bool
-	showVersion  bool
-	output       string
-	timeout      time.Duration
+	showDetails     bool
+	showResolved    bool
+	showMaintenance bool
+	showVersion     bool
+	flat            bool
+	sortBy          string
+	filter          componentFilter
+	window          timeWindow
+	impacts         []string
+	check           bool
+	watch           bool
+	until           string
+	incidentRef     string
+	interval        time.Duration
+	failOn          health
+	output          string
+	template        string
+	jq              string
+	statusPages     []string
+	timeout         time.Duration
+	retries         int
+	noCache         bool
+	offline         bool
+	maxAge          time.Duration
+	style           style
+	stepSummary     bool
 }
 
-func parseFlags(args []string) (config, error) {
-	cfg := config{
-		timeout: defaultTimeout,
-		output:  outputText,
+func defaultConfig() config {
+	return config{
+		timeout:  defaultTimeout,
+		output:   outputText,
+		retries:  defaultRetries,
+		sortBy:   sortByName,
+		failOn:   healthDegraded,
+		interval: defaultWatchInterval,
+		window:   timeWindow{Since: time.Now().Add(-defaultHistoryWindow)},
+	}
+}
+
+// structured reports whether the output is built from the full JSON
+// document, which carries every section regardless of the display flags.
+func (c config) structured() bool {
+	return c.output == outputJSON || c.output == outputTemplate
+}
+
+// commandFlags is a command's flag set preloaded with the global options
+// every command accepts: --json, --timeout, --status-page, --retries,
+// --no-cache, --max-age, --color and --icons.
+type commandFlags struct {
+	*flag.FlagSet
+	cfg        *config
+	jsonOutput bool
+	multiPage  bool
+	color      string
+	icons      string
+}
+
+func newCommandFlags(name, usage string, cfg *config, multiPage bool) *commandFlags {
+	f := &commandFlags{
+		FlagSet:   flag.NewFlagSet("gh-down "+name, flag.ContinueOnError),
+		cfg:       cfg,
+		multiPage: multiPage,
 	}
 
-	fs := flag.NewFlagSet("gh-down", flag.ContinueOnError)
+	pageHelp := "Statuspage.io site to query (default $" + statusPagesEnv + " or " + statusSiteURL + ")"
+	if multiPage {
+		pageHelp = "Statuspage.io site to query; repeat to combine several (default $" + statusPagesEnv + " or " + statusSiteURL + ")"
+	}
 
+	f.BoolVar(&f.jsonOutput, "json", false, "Emit machine-readable JSON")
+	f.DurationVar(&cfg.timeout, "timeout", cfg.timeout, "Override network timeout (e.g. 15s, 1m)")
+	f.Var((*stringList)(&cfg.statusPages), "status-page", pageHelp)
+	f.IntVar(&cfg.retries, "retries", cfg.retries, "Retry transient network and server errors this many times")
+	f.BoolVar(&cfg.noCache, "no-cache", cfg.noCache, "Bypass the on-disk response cache")
+	f.DurationVar(&cfg.maxAge, "max-age", 0, "Reuse cached responses younger than this without revalidating (default: honour Cache-Control)")
+	f.StringVar(&f.color, "color", colorAuto, "Color output: auto, always or never (auto honours NO_COLOR and CLICOLOR_FORCE)")
+	f.StringVar(&f.icons, "icons", iconsEmoji, "Status markers: emoji, ascii ([OK], [DEGRADED], [DOWN]) or none")
+
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Usage: %s\n\nOptions:\n", usage)
+		f.PrintDefaults()
+	}
+
+	return f
+}
+
+// parse parses args, validates the global options and returns any
+// positional arguments.
+func (f *commandFlags) parse(args []string) ([]string, error) {
+	positional, err := parseInterspersed(f.FlagSet, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := f.cfg
+	if cfg.timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be greater than zero")
+	}
+	if cfg.retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative")
+	}
+	if cfg.maxAge < 0 {
+		return nil, fmt.Errorf("max-age must not be negative")
+	}
+
+	fromEnv := false
+	if len(cfg.statusPages) == 0 {
+		cfg.statusPages = configuredStatusPages()
+		fromEnv = len(cfg.statusPages) > 0
+	}
+	if len(cfg.statusPages) == 0 {
+		cfg.statusPages = []string{statusSiteURL}
+	}
+	if len(cfg.statusPages) > 1 && !f.multiPage {
+		name := strings.TrimPrefix(f.Name(), "gh-down ")
+		if fromEnv {
+			return nil, fmt.Errorf("%s supports a single status page but $%s lists %d; pick one with --status-page", name, statusPagesEnv, len(cfg.statusPages))
+		}
+		return nil, fmt.Errorf("%s supports a single --status-page", name)
+	}
+	for i, raw := range cfg.statusPages {
+		page, err := normalizeStatusPage(raw)
+		if err != nil {
+			return nil, err
+		}
+		cfg.statusPages[i] = page
+	}
+
+	st, err := newStyle(f.color, f.icons, term.FromEnv())
+	if err != nil {
+		return nil, err
+	}
+	cfg.style = st
+
+	if f.jsonOutput {
+		cfg.output = outputJSON
+	}
+
+	return positional, nil
+}
+
+// addReportFlags registers the options that shape a status report.
+func addReportFlags(fs *commandFlags, cfg *config) {
 	fs.BoolVar(&cfg.showDetails, "details", false, "Show active incidents when available")
-	fs.BoolVar(&cfg.showResolved, "resolved", false, "Include recently resolved incidents (last 7 days)")
-	fs.BoolVar(&cfg.showVersion, "version", false, "Print version and exit")
-	fs.DurationVar(&cfg.timeout, "timeout", defaultTimeout, "Override network timeout (e.g. 15s, 1m)")
+	fs.BoolVar(&cfg.showResolved, "resolved", false, "Include resolved incidents from the --since/--until window")
+	fs.BoolVar(&cfg.showMaintenance, "maintenance", false, "Show upcoming and in-progress scheduled maintenance")
+	fs.Var((*stringList)(&cfg.filter.include), "component", "Only show components matching this name or glob; repeatable")
+	fs.Var((*stringList)(&cfg.filter.exclude), "exclude", "Hide components matching this name or glob; repeatable")
+	fs.BoolVar(&cfg.flat, "flat", false, "List components without their groups")
+	fs.StringVar(&cfg.sortBy, "sort", sortByName, "Order components by \"name\" or by status page \"position\"")
+}
 
-	jsonOutput := fs.Bool("json", false, "Emit machine-readable JSON")
+func validateReportFlags(cfg config) error {
+	if err := cfg.filter.validate(); err != nil {
+		return err
+	}
+
+	switch cfg.sortBy {
+	case sortByName, sortByPosition:
+	default:
+		return fmt.Errorf("sort must be %q or %q", sortByName, sortByPosition)
+	}
+
+	return nil
+}
+
+// parseFlags parses the options of the default status command. It also
+// accepts --watch so that "gh down --watch" keeps working.
+func parseFlags(args []string) (config, error) {
+	cfg := defaultConfig()
+
+	fs := newCommandFlags("status", "gh down [status] [options]", &cfg, true)
+	addReportFlags(fs, &cfg)
+
+	fs.BoolVar(&cfg.showVersion, "version", false, "Print version and exit")
+	fs.BoolVar(&cfg.offline, "offline", false, "Show the last saved snapshot without contacting the status page")
+	fs.BoolVar(&cfg.check, "check", false, "Exit with a health-based status code (3 degraded, 4 partial outage, 5 major outage)")
+	failOn := fs.String("fail-on", healthDegraded.String(), "Lowest health that makes --check fail: degraded, partial_outage or major_outage")
+	since := fs.String("since", "7d", "Show resolved incidents from this far back: a duration (72h, 30d) or a date (2026-09-01)")
+	until := fs.String("until", "", "Show resolved incidents up to this time (default: now)")
+	format := fs.String("format", "", "Output format: text, table, markdown or json (default text)")
+	fs.StringVar(&cfg.template, "template", "", "Format the report with a Go template; it sees the same fields as --json")
+	templateFile := fs.String("template-file", "", "Read the --template from a file")
+	fs.StringVar(&cfg.jq, "jq", "", "Filter the JSON report with a jq expression (implies --json)")
+	fs.BoolVar(&cfg.stepSummary, "step-summary", false, "Also append the report as Markdown to the GitHub Actions job summary")
+	fs.BoolVar(&cfg.watch, "watch", false, "Keep polling and print changes until interrupted (same as \"gh down watch\")")
+	fs.DurationVar(&cfg.interval, "interval", defaultWatchInterval, "Polling interval for --watch")
 
 	fs.Usage = func() {
-		fmt.Fprintln(fs.Output(), "Usage: gh down [options]")
+		fmt.Fprintf(fs.Output(), "Usage: gh down [<command>] [options]\n\nCommands:\n")
+		printCommands(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nRun \"gh down <command> --help\" for a command's options.\n\nStatus options:\n")
 		fs.PrintDefaults()
 	}
 
-	if err := fs.Parse(args); err != nil {
+	positional, err := fs.parse(args)
+	if err != nil {
 		return cfg, err
 	}
+	if len(positional) > 0 {
+		return cfg, fmt.Errorf("unknown command %q; run \"gh down --help\" for usage", positional[0])
+	}
 
-	if cfg.timeout <= 0 {
-		return cfg, fmt.Errorf("timeout must be greater than zero")
+	if cfg.interval <= 0 {
+		return cfg, fmt.Errorf("interval must be greater than zero")
+	}
+
+	threshold, err := parseHealth(*failOn)
+	if err != nil {
+		return cfg, err
+	}
+	if threshold == healthOperational {
+		return cfg, fmt.Errorf("fail-on must be degraded, partial_outage or major_outage")
+	}
+	cfg.failOn = threshold
+
+	if cfg.window, err = parseWindow(*since, *until, time.Now()); err != nil {
+		return cfg, err
 	}
 
-	if *jsonOutput {
+	switch *format {
+	case "":
+	case outputText, outputTable, outputMarkdown, outputJSON:
+		if cfg.output == outputJSON && *format != outputJSON {
+			return cfg, fmt.Errorf("--json cannot be combined with --format %s", *format)
+		}
+		cfg.output = *format
+	default:
+		return cfg, fmt.Errorf("format must be %q, %q, %q or %q", outputText, outputTable, outputMarkdown, outputJSON)
+	}
+
+	if *templateFile != "" {
+		if cfg.template != "" {
+			return cfg, fmt.Errorf("--template and --template-file cannot be combined")
+		}
+		data, err := os.ReadFile(*templateFile)
+		if err != nil {
+			return cfg, fmt.Errorf("read template: %w", err)
+		}
+		cfg.template = string(data)
+	}
+	if cfg.template != "" {
+		if *format != "" {
+			return cfg, fmt.Errorf("--template cannot be combined with --format")
+		}
+		if _, err := newTemplate(io.Discard, cfg.template, false); err != nil {
+			return cfg, err
+		}
+		cfg.output = outputTemplate
+	}
+	if cfg.jq != "" {
+		if cfg.template != "" {
+			return cfg, fmt.Errorf("--jq and --template cannot be combined")
+		}
+		if *format != "" && *format != outputJSON {
+			return cfg, fmt.Errorf("--jq cannot be combined with --format %s", *format)
+		}
+		if err := validateJQ(cfg.jq); err != nil {
+			return cfg, err
+		}
 		cfg.output = outputJSON
 	}
 
+	if err := validateReportFlags(cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.watch && len(cfg.statusPages) > 1 {
+		return cfg, fmt.Errorf("--watch supports a single status page")
+	}
+	if cfg.watch && (cfg.check || cfg.offline || cfg.template != "" || cfg.jq != "" || cfg.stepSummary) {
+		return cfg, fmt.Errorf("--watch cannot be combined with --check, --offline, --template, --jq or --step-summary")
+	}
+	if cfg.watch && (cfg.output == outputTable || cfg.output == outputMarkdown) {
+		return cfg, fmt.Errorf("--watch cannot be combined with --format %s", cfg.output)
+	}
+	if cfg.stepSummary && os.Getenv(stepSummaryEnv) == "" {
+		return cfg, fmt.Errorf("--step-summary requires $%s, which GitHub Actions sets", stepSummaryEnv)
+	}
+
+	return cfg, nil
+}
+
+func parseWatchFlags(args []string) (config, error) {
+	cfg := defaultConfig()
+	cfg.watch = true
+
+	fs := newCommandFlags("watch", "gh down watch [--interval <duration>] [options]", &cfg, false)
+	addReportFlags(fs, &cfg)
+	fs.DurationVar(&cfg.interval, "interval", defaultWatchInterval, "Polling interval")
+
+	positional, err := fs.parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if len(positional) > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+
+	if cfg.interval <= 0 {
+		return cfg, fmt.Errorf("interval must be greater than zero")
+	}
+
+	return cfg, validateReportFlags(cfg)
+}
+
+func parseWaitFlags(args []string) (config, error) {
+	cfg := defaultConfig()
+	cfg.timeout = defaultWaitTimeout
+	cfg.until = "operational"
+	// Every poll must see fresh data.
+	cfg.noCache = true
+
+	fs := newCommandFlags("wait", "gh down wait [--component <name>]... [--until <status>] [--timeout <duration>]", &cfg, false)
+	fs.Lookup("timeout").Usage = "Give up after this long"
+	fs.Lookup("json").Usage = "Print the final result as JSON"
+
+	fs.Var((*stringList)(&cfg.filter.include), "component", "Wait for components matching this name or glob; repeatable (default: all)")
+	fs.Var((*stringList)(&cfg.filter.exclude), "exclude", "Ignore components matching this name or glob; repeatable")
+	fs.StringVar(&cfg.until, "until", cfg.until, "Target status: "+strings.Join(waitTargets, ", "))
+	fs.DurationVar(&cfg.interval, "interval", defaultWatchInterval, "Polling interval")
+
+	positional, err := fs.parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if len(positional) > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+
+	if cfg.interval <= 0 {
+		return cfg, fmt.Errorf("interval must be greater than zero")
+	}
+
+	cfg.until = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(cfg.until)), "-", "_")
+	if !validWaitTarget(cfg.until) {
+		return cfg, fmt.Errorf("until must be one of %s", strings.Join(waitTargets, ", "))
+	}
+
+	return cfg, cfg.filter.validate()
+}
+
+func parseIncidentFlags(args []string) (config, error) {
+	cfg := defaultConfig()
+
+	fs := newCommandFlags("incident", "gh down incident <id|shortlink> [options]", &cfg, false)
+
+	positional, err := fs.parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if len(positional) != 1 {
+		return cfg, fmt.Errorf("expected exactly one incident ID or shortlink; run \"gh down incident --help\" for usage")
+	}
+	cfg.incidentRef = positional[0]
+
 	return cfg, nil
 }
+
+func parseHistoryFlags(args []string) (config, error) {
+	return parseHistoryQueryFlags("history", "gh down history [--since <time>] [--until <time>] [--component <name>]... [--impact <level>]...", "30d", args)
+}
+
+func parseStatsFlags(args []string) (config, error) {
+	return parseHistoryQueryFlags("stats", "gh down stats [--since <time>] [--until <time>] [--component <name>]... [--impact <level>]...", "90d", args)
+}
+
+// parseAtFlags parses "gh down at <time|range>". The time may span several
+// arguments, so "gh down at 2h ago" works unquoted.
+func parseAtFlags(args []string) (config, error) {
+	cfg := defaultConfig()
+
+	fs := newCommandFlags("at", "gh down at <time>|<from>..<to> [options]", &cfg, false)
+	fs.Var((*stringList)(&cfg.filter.include), "component", "Only consider incidents affecting components matching this name or glob; repeatable")
+	fs.Var((*stringList)(&cfg.filter.exclude), "exclude", "Ignore incidents that only affect components matching this name or glob; repeatable")
+	fs.BoolVar(&cfg.offline, "offline", false, "Use the local history without refreshing it from the status page")
+
+	positional, err := fs.parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if len(positional) == 0 {
+		return cfg, fmt.Errorf("expected a time or range such as \"2h ago\" or 2026-10-15T14:00..2026-10-15T16:00; run \"gh down at --help\" for usage")
+	}
+
+	if cfg.window, err = parseRange(strings.Join(positional, " "), time.Now()); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.filter.validate()
+}
+
+// parseHistoryQueryFlags parses the options shared by the commands that
+// query incident history.
+func parseHistoryQueryFlags(name, usage, defaultSince string, args []string) (config, error) {
+	cfg := defaultConfig()
+
+	fs := newCommandFlags(name, usage, &cfg, false)
+	since := fs.String("since", defaultSince, "Start of the period: a duration (72h, 30d) or a date (2026-09-01)")
+	until := fs.String("until", "", "End of the period (default: now)")
+	fs.Var((*stringList)(&cfg.filter.include), "component", "Only include incidents affecting components matching this name or glob; repeatable")
+	fs.Var((*stringList)(&cfg.filter.exclude), "exclude", "Leave out incidents that only affect components matching this name or glob; repeatable")
+	fs.Var((*stringList)(&cfg.impacts), "impact", "Only include incidents with this impact: "+strings.Join(historyImpacts, ", ")+"; repeatable")
+	fs.BoolVar(&cfg.offline, "offline", false, "Use the local history without refreshing it from the status page")
+
+	positional, err := fs.parse(args)
+	if err != nil {
+		return cfg, err
+	}
+	if len(positional) > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+
+	if cfg.window, err = parseWindow(*since, *until, time.Now()); err != nil {
+		return cfg, err
+	}
+	if cfg.window.Since.IsZero() {
+		return cfg, fmt.Errorf("since must not be empty")
+	}
+
+	for i, impact := range cfg.impacts {
+		impact = strings.ToLower(strings.TrimSpace(impact))
+		if !validHistoryImpact(impact) {
+			return cfg, fmt.Errorf("impact must be one of %s", strings.Join(historyImpacts, ", "))
+		}
+		cfg.impacts[i] = impact
+	}
+
+	return cfg, cfg.filter.validate()
+}
+
+// normalizeStatusPage validates a Statuspage base URL and returns it with a
+// scheme and a trailing slash. A bare host such as "status.npmjs.org" is
+// assumed to be served over https.
+func normalizeStatusPage(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("status page must not be empty")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid status page %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid status page %q: expected an http(s) URL", raw)
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/"
+	return u.String(), nil
+}
+
+// configuredStatusPages returns the status pages listed in
+// $GH_DOWN_STATUS_PAGES, if any.
+func configuredStatusPages() []string {
+	return strings.FieldsFunc(os.Getenv(statusPagesEnv), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments and returns the positional arguments in order.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
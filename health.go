@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// health is the overall state of a report, ordered from best to worst.
+type health int
+
+const (
+	healthOperational health = iota
+	healthDegraded
+	healthPartialOutage
+	healthMajorOutage
+)
+
+// Exit codes used by --check. 1 and 2 are reserved for fetch/render errors
+// and usage errors so scripts can tell "the service is down" from "we could
+// not ask".
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitUsage         = 2
+	exitDegraded      = 3
+	exitPartialOutage = 4
+	exitMajorOutage   = 5
+	// exitTimedOut is used by "gh down wait" when the deadline passes before
+	// the components recover.
+	exitTimedOut = 6
+)
+
+var healthNames = map[health]string{
+	healthOperational:   "operational",
+	healthDegraded:      "degraded",
+	healthPartialOutage: "partial_outage",
+	healthMajorOutage:   "major_outage",
+}
+
+func (h health) String() string {
+	return healthNames[h]
+}
+
+func (h health) exitCode() int {
+	switch h {
+	case healthDegraded:
+		return exitDegraded
+	case healthPartialOutage:
+		return exitPartialOutage
+	case healthMajorOutage:
+		return exitMajorOutage
+	default:
+		return exitOK
+	}
+}
+
+func parseHealth(raw string) (health, error) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), "-", "_")
+	for h, candidate := range healthNames {
+		if name == candidate {
+			return h, nil
+		}
+	}
+	return healthOperational, fmt.Errorf("unknown health level %q (want degraded, partial_outage or major_outage)", raw)
+}
+
+// componentHealth maps a Statuspage component status onto health.
+func componentHealth(status string) health {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "operational":
+		return healthOperational
+	case "partial_outage":
+		return healthPartialOutage
+	case "major_outage":
+		return healthMajorOutage
+	default:
+		return healthDegraded
+	}
+}
+
+// impactHealth maps an unresolved incident's impact onto health.
+func impactHealth(impact string) health {
+	switch strings.ToLower(strings.TrimSpace(impact)) {
+	case "critical":
+		return healthMajorOutage
+	case "major":
+		return healthPartialOutage
+	case "minor":
+		return healthDegraded
+	default:
+		return healthOperational
+	}
+}
+
+// reportHealth is the worst health across the report's components and
+// active incidents.
+func reportHealth(r report) health {
+	worst := healthOperational
+	for _, comp := range r.Components {
+		worst = max(worst, componentHealth(comp.Status))
+	}
+	for _, inc := range r.Active {
+		worst = max(worst, impactHealth(inc.Impact))
+	}
+	return worst
+}
+
+// checkExitCode returns the --check exit code for h given the --fail-on
+// threshold.
+func checkExitCode(h health, threshold health) int {
+	if h < threshold {
+		return exitOK
+	}
+	return h.exitCode()
+}
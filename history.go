@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// historyStore keeps every incident update and component status seen on a
+// status page in an append-only JSON lines file, so history reaches further
+// back than the status page's own incident feed. A sidecar file lists the
+// keys of everything stored, so recording an observation only reads that
+// list, once per process, instead of the whole history.
+type historyStore struct {
+	dir string
+	// mu serializes recording and guards seen.
+	mu sync.Mutex
+	// seen holds the stored keys of each status page once loaded.
+	seen map[string]map[string]struct{}
+}
+
+// historyRecord is one line of the store. Exactly one of Incident, Update
+// and Component is set. Incident carries an incident's metadata without its
+// updates, which are stored one per record with IncidentID naming their
+// incident.
+type historyRecord struct {
+	ObservedAt time.Time       `json:"observed_at"`
+	Incident   *incident       `json:"incident,omitempty"`
+	IncidentID string          `json:"incident_id,omitempty"`
+	Update     *incidentUpdate `json:"update,omitempty"`
+	Component  *component      `json:"component,omitempty"`
+}
+
+// key identifies the revision a record holds: incident metadata by ID and
+// updated_at, an update by incident ID, timestamp and status, and a
+// component by ID (or name), updated_at and status.
+func (r historyRecord) key() string {
+	switch {
+	case r.Incident != nil:
+		return "incident:" + r.Incident.ID + "@" + r.Incident.UpdatedAt
+	case r.Update != nil:
+		return "update:" + r.IncidentID + "@" + r.Update.CreatedAt + "=" + r.Update.Status
+	case r.Component != nil:
+		return "component:" + componentKey(*r.Component) + "@" + r.Component.UpdatedAt + "=" + r.Component.Status
+	default:
+		return ""
+	}
+}
+
+func newHistoryStore(dir string) *historyStore {
+	return &historyStore{dir: dir}
+}
+
+// defaultHistoryDir returns the gh-down history directory under the user
+// data dir. History is not a cache and should survive cache cleanups.
+func defaultHistoryDir() (string, error) {
+	base, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gh-down", "history"), nil
+}
+
+func userDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return dir, nil
+		}
+		return os.UserConfigDir()
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support"), nil
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
+
+func (h *historyStore) path(statusPage string) string {
+	return keyedPath(h.dir, statusPage, ".jsonl")
+}
+
+func (h *historyStore) keysPath(statusPage string) string {
+	return keyedPath(h.dir, statusPage, ".keys")
+}
+
+func (h *historyStore) read(statusPage string) ([]historyRecord, error) {
+	f, err := os.Open(h.path(statusPage))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []historyRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec historyRecord
+		// A torn final line from an interrupted write is skipped, not fatal.
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+func (h *historyStore) append(statusPage string, records []historyRecord) error {
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path(statusPage), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// seenKeys returns the keys stored for statusPage, loading them on first
+// use.
+func (h *historyStore) seenKeys(statusPage string) (map[string]struct{}, error) {
+	if seen, ok := h.seen[statusPage]; ok {
+		return seen, nil
+	}
+
+	seen := make(map[string]struct{})
+	if _, err := os.Stat(h.path(statusPage)); os.IsNotExist(err) {
+		// Keys left behind by a deleted history would hide new records.
+		os.Remove(h.keysPath(statusPage))
+	} else if err := h.loadKeys(statusPage, seen); err != nil {
+		return nil, err
+	}
+
+	if h.seen == nil {
+		h.seen = make(map[string]map[string]struct{})
+	}
+	h.seen[statusPage] = seen
+	return seen, nil
+}
+
+// loadKeys adds the keys in statusPage's sidecar file to seen.
+func (h *historyStore) loadKeys(statusPage string, seen map[string]struct{}) error {
+	data, err := os.ReadFile(h.keysPath(statusPage))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, key := range strings.Split(string(data), "\n") {
+		if key != "" {
+			seen[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// record appends what one response showed that is not stored yet: each new
+// incident revision and update, and each component status change.
+func (h *historyStore) record(statusPage string, incidents []incident, components []component, now time.Time) error {
+	if h == nil {
+		return nil
+	}
+	records := observationRecords(incidents, components, now)
+	if len(records) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen, err := h.seenKeys(statusPage)
+	if err != nil {
+		return err
+	}
+	var (
+		fresh []historyRecord
+		keys  strings.Builder
+	)
+	added := make(map[string]struct{})
+	for _, rec := range records {
+		key := rec.key()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if _, ok := added[key]; ok {
+			continue
+		}
+		added[key] = struct{}{}
+		fresh = append(fresh, rec)
+		keys.WriteString(key + "\n")
+	}
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	if err := h.append(statusPage, fresh); err != nil {
+		return err
+	}
+	for key := range added {
+		seen[key] = struct{}{}
+	}
+	// A key lost here only means the record may be stored twice.
+	f, err := os.OpenFile(h.keysPath(statusPage), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(keys.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// observationRecords splits incidents into metadata and update records and
+// adds a record per component.
+func observationRecords(incidents []incident, components []component, now time.Time) []historyRecord {
+	now = now.UTC()
+	var records []historyRecord
+	for _, inc := range incidents {
+		if inc.ID == "" {
+			continue
+		}
+		meta := inc
+		meta.IncidentUpdates = nil
+		records = append(records, historyRecord{ObservedAt: now, Incident: &meta})
+		for _, upd := range inc.IncidentUpdates {
+			upd := upd
+			records = append(records, historyRecord{ObservedAt: now, IncidentID: inc.ID, Update: &upd})
+		}
+	}
+	for _, comp := range components {
+		if componentKey(comp) == "" || comp.Name == referenceComponent {
+			continue
+		}
+		comp := comp
+		comp.Components = nil
+		records = append(records, historyRecord{ObservedAt: now, Component: &comp})
+	}
+	return records
+}
+
+// incidents returns every stored incident with its stored updates. Of
+// several revisions of the same incident, the latest wins.
+func (h *historyStore) incidents(statusPage string) ([]incident, error) {
+	if h == nil {
+		return nil, nil
+	}
+	h.mu.Lock()
+	records, err := h.read(statusPage)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]incident)
+	updates := make(map[string][]incidentUpdate)
+	var order []string
+	for _, rec := range records {
+		if rec.Update != nil && rec.IncidentID != "" {
+			updates[rec.IncidentID] = append(updates[rec.IncidentID], *rec.Update)
+			continue
+		}
+		if rec.Incident == nil || rec.Incident.ID == "" {
+			continue
+		}
+		inc := *rec.Incident
+		prev, ok := byID[inc.ID]
+		if !ok {
+			order = append(order, inc.ID)
+			byID[inc.ID] = inc
+			continue
+		}
+		if !incidentTime(inc).Before(incidentTime(prev)) {
+			byID[inc.ID] = inc
+		}
+	}
+
+	merged := make([]incident, 0, len(order))
+	for _, id := range order {
+		inc := byID[id]
+		inc.IncidentUpdates = sortUpdates(updates[id])
+		merged = append(merged, inc)
+	}
+	return merged, nil
+}
+
+// components returns the last stored status of every component, groups
+// included, in the order they were first seen.
+func (h *historyStore) components(statusPage string) ([]component, error) {
+	if h == nil {
+		return nil, nil
+	}
+	h.mu.Lock()
+	records, err := h.read(statusPage)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]component)
+	var order []string
+	for _, rec := range records {
+		if rec.Component == nil {
+			continue
+		}
+		key := componentKey(*rec.Component)
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = *rec.Component
+	}
+
+	comps := make([]component, 0, len(order))
+	for _, key := range order {
+		comps = append(comps, byKey[key])
+	}
+	return comps, nil
+}
+
+// sortUpdates returns updates without duplicates, newest first.
+func sortUpdates(updates []incidentUpdate) []incidentUpdate {
+	seen := make(map[string]struct{}, len(updates))
+	sorted := make([]incidentUpdate, 0, len(updates))
+	for _, upd := range updates {
+		key := upd.ID
+		if key == "" {
+			key = upd.CreatedAt + "|" + upd.Status
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		sorted = append(sorted, upd)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, _ := parseTime(sorted[i].CreatedAt)
+		tj, _ := parseTime(sorted[j].CreatedAt)
+		return ti.After(tj)
+	})
+	return sorted
+}
+
+// loadHistory returns the incidents known for client's status page: those
+// the status page serves now, unless cfg.offline is set, merged with the
+// local history store. If the refresh fails, the stored incidents are
+// still returned along with refreshErr, so callers decide whether history
+// that may be out of date is good enough.
+func loadHistory(ctx context.Context, client *statusClient, cfg config) (incidents []incident, refreshErr error, err error) {
+	var fetched []incident
+	if !cfg.offline {
+		resolved, err := client.ResolvedIncidents(ctx, cfg.window)
+		if err == nil {
+			var active []incident
+			active, err = client.ActiveIncidents(ctx)
+			fetched = append(active, resolved...)
+		}
+		if err != nil {
+			refreshErr = fmt.Errorf("refresh history: %w", err)
+		}
+	}
+
+	stored, err := client.history.incidents(client.siteURL)
+	if err != nil {
+		return nil, refreshErr, err
+	}
+	if client.history == nil && cfg.offline {
+		return nil, nil, fmt.Errorf("no user data directory for the history store")
+	}
+
+	known := make(map[string]struct{}, len(stored))
+	for _, inc := range stored {
+		known[inc.ID] = struct{}{}
+	}
+	for _, inc := range fetched {
+		if _, ok := known[inc.ID]; !ok {
+			stored = append(stored, inc)
+		}
+	}
+	return stored, refreshErr, nil
+}
+
+// printRefreshWarning notes that the output comes from the history store
+// alone because refreshing it from the status page failed.
+func printRefreshWarning(w io.Writer, refreshErr error, st style) {
+	if refreshErr == nil {
+		return
+	}
+	fmt.Fprintf(w, "%sShowing recorded history only; the status page could not be reached.\n  %v\n\n", st.warning(), refreshErr)
+}
+
+// loadComponents returns the components of client's status page: the
+// current list, unless cfg.offline is set or it cannot be fetched, and the
+// statuses last recorded in the history store otherwise.
+func loadComponents(ctx context.Context, client *statusClient, cfg config) []component {
+	if !cfg.offline {
+		if comps, err := client.Components(ctx); err == nil {
+			return comps
+		}
+	}
+	comps, _ := client.history.components(client.siteURL)
+	return comps
+}
+
+// loadGroups returns the component groups of client's status page when
+// cfg's filter needs them to match incidents by group name.
+func loadGroups(ctx context.Context, client *statusClient, cfg config) componentGroups {
+	if cfg.filter.empty() {
+		return componentGroups{}
+	}
+	return newComponentGroups(loadComponents(ctx, client, cfg))
+}
+
+var historyImpacts = []string{"none", "minor", "major", "critical", "maintenance"}
+
+func validHistoryImpact(impact string) bool {
+	for _, known := range historyImpacts {
+		if impact == known {
+			return true
+		}
+	}
+	return false
+}
+
+// queryHistory returns the incidents whose span overlaps window, that affect
+// a component selected by filter and, when impacts is not empty, have one of
+// those impacts. The result is ordered newest first.
+func queryHistory(incidents []incident, window timeWindow, filter componentFilter, groups componentGroups, impacts []string, now time.Time) []incidentTimeline {
+	var out []incidentTimeline
+	for _, inc := range incidents {
+		if len(impacts) > 0 && !containsString(impacts, strings.ToLower(strings.TrimSpace(inc.Impact))) {
+			continue
+		}
+		if !incidentSelected(inc, filter, groups) {
+			continue
+		}
+
+		tl := buildTimeline(inc, now)
+		if tl.Start.IsZero() {
+			tl.Start = incidentTime(inc)
+		}
+		if tl.Start.IsZero() {
+			continue
+		}
+		if !window.Until.IsZero() && tl.Start.After(window.Until) {
+			continue
+		}
+		if !window.Since.IsZero() && tl.End.Before(window.Since) {
+			continue
+		}
+		out = append(out, tl)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start.After(out[j].Start)
+	})
+	return out
+}
+
+// incidentSelected reports whether inc affects a component the filter
+// selects, by the component's name or its group's, like filterComponents.
+// Components named only in updates count, as they do for the status report.
+// Incidents without components only pass an include-less filter.
+func incidentSelected(inc incident, filter componentFilter, groups componentGroups) bool {
+	if filter.empty() {
+		return true
+	}
+	comps := incidentComponents(inc)
+	if len(comps) == 0 {
+		return len(filter.include) == 0
+	}
+	for _, comp := range comps {
+		if filter.selects(comp.Name, groups.of(comp)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func renderHistoryText(w io.Writer, timelines []incidentTimeline, window timeWindow, refreshErr error, now time.Time, st style) {
+	printRefreshWarning(w, refreshErr, st)
+	if len(timelines) == 0 {
+		fmt.Fprintf(w, "No incidents %s.\n", window.describe(now))
+		return
+	}
+
+	fmt.Fprintf(w, "Incidents %s:\n", window.describe(now))
+	for _, tl := range timelines {
+		inc := tl.Incident
+		fmt.Fprintf(w, "\n%s%s\n", st.icon(inc.Impact), inc.Name)
+		fmt.Fprintf(w, "  ID: %s\n", inc.ID)
+		if impact := st.status(inc.Impact); impact != "" {
+			fmt.Fprintf(w, "  Impact: %s\n", impact)
+		}
+		fmt.Fprintf(w, "  Started: %s\n", tl.Start.Local().Format("Jan 02 2006 15:04"))
+		if tl.Ongoing {
+			fmt.Fprintf(w, "  Duration: %s so far\n", formatAge(tl.Duration()))
+		} else {
+			fmt.Fprintf(w, "  Duration: %s\n", formatAge(tl.Duration()))
+		}
+		if names := incidentComponentNames(inc); len(names) > 0 {
+			fmt.Fprintf(w, "  Affected components: %s\n", strings.Join(names, ", "))
+		}
+		if inc.Shortlink != "" {
+			fmt.Fprintf(w, "  More info: %s\n", inc.Shortlink)
+		}
+	}
+}
+
+func renderHistoryJSON(w io.Writer, statusPage string, timelines []incidentTimeline, window timeWindow, refreshErr error) error {
+	payload := jsonHistory{
+		StatusPage: statusPage,
+		Stale:      refreshErr != nil,
+		Incidents:  make([]jsonIncidentDetail, 0, len(timelines)),
+	}
+	if refreshErr != nil {
+		payload.RefreshError = refreshErr.Error()
+	}
+	if !window.Since.IsZero() {
+		payload.Since = window.Since.UTC().Format(time.RFC3339)
+	}
+	if !window.Until.IsZero() {
+		payload.Until = window.Until.UTC().Format(time.RFC3339)
+	}
+	for _, tl := range timelines {
+		payload.Incidents = append(payload.Incidents, buildJSONIncidentDetail(tl))
+	}
+	return encodeJSON(w, payload)
+}
+
+type jsonHistory struct {
+	StatusPage string `json:"status_page"`
+	// Stale marks history that could not be refreshed from the status page.
+	Stale        bool                 `json:"stale"`
+	RefreshError string               `json:"refresh_error,omitempty"`
+	Since        string               `json:"since,omitempty"`
+	Until        string               `json:"until,omitempty"`
+	Incidents    []jsonIncidentDetail `json:"incidents"`
+}
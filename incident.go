@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// incidentTimeline is a single incident with its updates in chronological
+// order and the overall span it covered.
+type incidentTimeline struct {
+	Incident incident
+	Start    time.Time
+	End      time.Time
+	Ongoing  bool
+	Entries  []timelineEntry
+}
+
+type timelineEntry struct {
+	Update        incidentUpdate
+	At            time.Time
+	SincePrevious time.Duration
+}
+
+// Duration is how long the incident lasted, or has lasted so far.
+func (t incidentTimeline) Duration() time.Duration {
+	if t.Start.IsZero() || t.End.IsZero() || t.End.Before(t.Start) {
+		return 0
+	}
+	return t.End.Sub(t.Start)
+}
+
+func buildTimeline(inc incident, now time.Time) incidentTimeline {
+	tl := incidentTimeline{Incident: inc}
+
+	entries := make([]timelineEntry, 0, len(inc.IncidentUpdates))
+	for _, upd := range inc.IncidentUpdates {
+		at, _ := parseTime(upd.CreatedAt)
+		entries = append(entries, timelineEntry{Update: upd, At: at})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+	for i := 1; i < len(entries); i++ {
+		if !entries[i].At.IsZero() && !entries[i-1].At.IsZero() {
+			entries[i].SincePrevious = entries[i].At.Sub(entries[i-1].At)
+		}
+	}
+	tl.Entries = entries
+
+	for _, raw := range []string{inc.StartedAt, inc.CreatedAt} {
+		if t, ok := parseTime(raw); ok {
+			tl.Start = t
+			break
+		}
+	}
+	if tl.Start.IsZero() && len(entries) > 0 {
+		tl.Start = entries[0].At
+	}
+
+	if t, ok := parseTime(inc.ResolvedAt); ok {
+		tl.End = t
+	} else if incidentClosed(inc.Status) {
+		// A postmortem can follow long after the fix; the first closing
+		// update marks the resolution.
+		for _, entry := range entries {
+			if incidentClosed(entry.Update.Status) {
+				tl.End = entry.At
+				break
+			}
+		}
+		if tl.End.IsZero() && len(entries) > 0 {
+			tl.End = entries[len(entries)-1].At
+		}
+	} else {
+		tl.End = now
+		tl.Ongoing = true
+	}
+
+	return tl
+}
+
+// incidentClosed reports whether status ends an incident.
+func incidentClosed(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "resolved", "postmortem", "completed":
+		return true
+	default:
+		return false
+	}
+}
+
+// incidentComponents returns the components an incident touches: those
+// attached to the incident plus any named in its updates' affected
+// components.
+func incidentComponents(inc incident) []component {
+	seen := make(map[string]struct{}, len(inc.Components))
+	out := make([]component, 0, len(inc.Components))
+	add := func(comp component) {
+		key := componentKey(comp)
+		if key == "" {
+			return
+		}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		// Updates name components by code; catch the same component under
+		// its name too.
+		if _, ok := seen[normalizeName(comp.Name)]; ok && comp.Name != "" {
+			return
+		}
+		seen[key] = struct{}{}
+		seen[normalizeName(comp.Name)] = struct{}{}
+		out = append(out, comp)
+	}
+
+	for _, comp := range inc.Components {
+		add(comp)
+	}
+	for _, upd := range inc.IncidentUpdates {
+		for _, change := range upd.AffectedComponents {
+			add(component{ID: change.Code, Name: change.Name, Status: change.NewStatus})
+		}
+	}
+	return out
+}
+
+func incidentComponentNames(inc incident) []string {
+	comps := incidentComponents(inc)
+	names := make([]string, 0, len(comps))
+	for _, comp := range comps {
+		names = append(names, comp.Name)
+	}
+	return names
+}
+
+func renderIncidentText(w io.Writer, tl incidentTimeline, st style) {
+	inc := tl.Incident
+	fmt.Fprintf(w, "%s%s\n", st.icon(inc.Status), inc.Name)
+	fmt.Fprintf(w, "  ID: %s\n", inc.ID)
+	if impact := st.status(inc.Impact); impact != "" {
+		fmt.Fprintf(w, "  Impact: %s\n", impact)
+	}
+	fmt.Fprintf(w, "  Status: %s\n", st.status(inc.Status))
+	if !tl.Start.IsZero() {
+		fmt.Fprintf(w, "  Started: %s\n", tl.Start.Local().Format("Jan 02 15:04"))
+	}
+	if tl.Ongoing {
+		fmt.Fprintf(w, "  Duration: %s so far\n", formatAge(tl.Duration()))
+	} else if !tl.End.IsZero() {
+		fmt.Fprintf(w, "  Resolved: %s\n", tl.End.Local().Format("Jan 02 15:04"))
+		fmt.Fprintf(w, "  Duration: %s\n", formatAge(tl.Duration()))
+	}
+	if names := incidentComponentNames(inc); len(names) > 0 {
+		fmt.Fprintf(w, "  Affected components: %s\n", strings.Join(names, ", "))
+	}
+	if inc.Shortlink != "" {
+		fmt.Fprintf(w, "  More info: %s\n", inc.Shortlink)
+	}
+
+	fmt.Fprintln(w, "\nTimeline:")
+	if len(tl.Entries) == 0 {
+		fmt.Fprintln(w, "  No updates posted.")
+		return
+	}
+	for i, entry := range tl.Entries {
+		gap := ""
+		if i > 0 && entry.SincePrevious > 0 {
+			gap = fmt.Sprintf(" (%s later)", formatAge(entry.SincePrevious))
+		}
+		fmt.Fprintf(w, "  - [%s]%s %s: %s\n",
+			formatTimestamp(entry.Update.CreatedAt),
+			gap,
+			st.status(entry.Update.Status),
+			summarizeBody(entry.Update.Body),
+		)
+		for _, change := range entry.Update.AffectedComponents {
+			fmt.Fprintf(w, "      %s%s: %s → %s\n",
+				st.icon(change.NewStatus),
+				change.Name,
+				st.status(change.OldStatus),
+				st.status(change.NewStatus),
+			)
+		}
+	}
+}
+
+func renderIncidentJSON(w io.Writer, tl incidentTimeline) error {
+	return encodeJSON(w, buildJSONIncidentDetail(tl))
+}
+
+func buildJSONIncidentDetail(tl incidentTimeline) jsonIncidentDetail {
+	inc := tl.Incident
+	payload := jsonIncidentDetail{
+		ID:         inc.ID,
+		Name:       inc.Name,
+		Impact:     strings.ToLower(strings.TrimSpace(inc.Impact)),
+		Status:     strings.ToLower(strings.TrimSpace(inc.Status)),
+		StatusText: formatStatus(inc.Status),
+		Shortlink:  inc.Shortlink,
+		Ongoing:    tl.Ongoing,
+		Components: incidentComponentNames(inc),
+		Timeline:   make([]jsonTimelineEntry, 0, len(tl.Entries)),
+	}
+	if !tl.Start.IsZero() {
+		payload.StartedAt = tl.Start.UTC().Format(time.RFC3339)
+	}
+	if !tl.Ongoing && !tl.End.IsZero() {
+		payload.ResolvedAt = tl.End.UTC().Format(time.RFC3339)
+	}
+	payload.DurationSeconds = int64(tl.Duration() / time.Second)
+
+	for _, entry := range tl.Entries {
+		item := jsonTimelineEntry{
+			Status:               strings.ToLower(strings.TrimSpace(entry.Update.Status)),
+			StatusText:           formatStatus(entry.Update.Status),
+			Body:                 summarizeBody(entry.Update.Body),
+			CreatedAt:            entry.Update.CreatedAt,
+			SincePreviousSeconds: int64(entry.SincePrevious / time.Second),
+		}
+		for _, change := range entry.Update.AffectedComponents {
+			item.AffectedComponents = append(item.AffectedComponents, jsonComponentChange{
+				Name:      change.Name,
+				OldStatus: change.OldStatus,
+				NewStatus: change.NewStatus,
+			})
+		}
+		payload.Timeline = append(payload.Timeline, item)
+	}
+
+	return payload
+}
+
+type jsonIncidentDetail struct {
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Impact          string              `json:"impact"`
+	Status          string              `json:"status"`
+	StatusText      string              `json:"status_text"`
+	Shortlink       string              `json:"shortlink,omitempty"`
+	StartedAt       string              `json:"started_at,omitempty"`
+	ResolvedAt      string              `json:"resolved_at,omitempty"`
+	Ongoing         bool                `json:"ongoing"`
+	DurationSeconds int64               `json:"duration_seconds"`
+	Components      []string            `json:"components"`
+	Timeline        []jsonTimelineEntry `json:"timeline"`
+}
+
+type jsonTimelineEntry struct {
+	Status               string                `json:"status"`
+	StatusText           string                `json:"status_text"`
+	Body                 string                `json:"body"`
+	CreatedAt            string                `json:"created_at"`
+	SincePreviousSeconds int64                 `json:"since_previous_seconds"`
+	AffectedComponents   []jsonComponentChange `json:"affected_components,omitempty"`
+}
+
+type jsonComponentChange struct {
+	Name      string `json:"name"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+}
@@ -5,37 +5,404 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/term"
 )
 
+// command is a gh down subcommand. run receives the arguments after the
+// command name and returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+func commands() []command {
+	return []command{
+		{name: "status", summary: "Show service status (default)", run: runStatusCommand},
+		{name: "watch", summary: "Poll the status page and print what changes", run: runWatchCommand},
+		{name: "incident", summary: "Show the full timeline of one incident", run: runIncidentCommand},
+		{name: "wait", summary: "Block until components recover", run: runWaitCommand},
+		{name: "history", summary: "Query the locally recorded incident history", run: runHistoryCommand},
+		{name: "stats", summary: "Summarize incidents, availability and MTTR per component", run: runStatsCommand},
+		{name: "at", summary: "Show incidents that overlapped a time or range", run: runAtCommand},
+	}
+}
+
+func printCommands(w io.Writer) {
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
 func main() {
-	cfg, err := parseFlags(os.Args[1:])
-	if err != nil {
-		if errors.Is(err, flag.ErrHelp) {
-			return
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches args to a subcommand. Anything that does not start with a
+// command name is handled by status, so "gh down --details" keeps working.
+func run(args []string) int {
+	if len(args) > 0 {
+		name, rest := args[0], args[1:]
+		if name == "help" {
+			if len(rest) == 0 {
+				return runStatusCommand([]string{"--help"})
+			}
+			name, rest = rest[0], []string{"--help"}
+			if _, ok := lookupCommand(name); !ok {
+				fmt.Fprintf(os.Stderr, "unknown command %q\n\nCommands:\n", name)
+				printCommands(os.Stderr)
+				return exitUsage
+			}
 		}
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(2)
+		if cmd, ok := lookupCommand(name); ok {
+			return cmd.run(rest)
+		}
+	}
+	return runStatusCommand(args)
+}
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// usageExit reports a flag parsing error and returns the matching exit code.
+func usageExit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return exitUsage
+}
+
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+func runStatusCommand(args []string) int {
+	cfg, err := parseFlags(args)
+	if err != nil {
+		return usageExit(err)
 	}
 
 	if cfg.showVersion {
 		fmt.Printf("gh-down %s\n", version)
-		return
+		return exitOK
+	}
+
+	if cfg.watch {
+		return watchStatus(cfg)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
 	defer cancel()
 
-	client := newStatusClient(cfg.timeout)
+	if len(cfg.statusPages) > 1 {
+		return runProviders(ctx, cfg)
+	}
+
+	client := newClient(cfg, cfg.statusPages[0])
 
-	rep, err := buildReport(ctx, client, cfg)
+	rep, err := fetchReport(ctx, client, newSnapshots(), cfg)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return exitError
 	}
 
 	if err := renderReport(rep, cfg); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return exitError
+	}
+	if cfg.stepSummary {
+		if err := appendStepSummary(func(w io.Writer) { renderMarkdown(w, rep, cfg) }); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	if cfg.check {
+		// A snapshot we fell back to says nothing about the service now.
+		if rep.Stale && !cfg.offline {
+			return exitError
+		}
+		return checkExitCode(reportHealth(rep), cfg.failOn)
+	}
+	return exitOK
+}
+
+func runProviders(ctx context.Context, cfg config) int {
+	clients := make([]*statusClient, 0, len(cfg.statusPages))
+	for _, page := range cfg.statusPages {
+		clients = append(clients, newClient(cfg, page))
+	}
+
+	providers := buildProviderReports(ctx, clients, newSnapshots(), cfg)
+
+	if err := renderProviders(providers, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if cfg.stepSummary {
+		if err := appendStepSummary(func(w io.Writer) { renderProvidersMarkdown(w, providers, cfg) }); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	worst := healthOperational
+	failed, reachable := 0, 0
+	for _, p := range providers {
+		if p.Err != nil || (p.Stale && !cfg.offline) {
+			failed++
+		}
+		if p.Err == nil {
+			reachable++
+			worst = max(worst, reportHealth(p.report))
+		}
+	}
+
+	if reachable == 0 {
+		return exitError
+	}
+	if cfg.check {
+		if code := checkExitCode(worst, cfg.failOn); code != exitOK {
+			return code
+		}
+		if failed > 0 {
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+func runWatchCommand(args []string) int {
+	cfg, err := parseWatchFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+	return watchStatus(cfg)
+}
+
+func watchStatus(cfg config) int {
+	ctx, stop := signalContext()
+	defer stop()
+
+	client := newClient(cfg, cfg.statusPages[0])
+	if err := runWatch(ctx, client, cfg, os.Stdout, term.FromEnv().IsTerminalOutput()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runWaitCommand(args []string) int {
+	cfg, err := parseWaitFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	client := newClient(cfg, cfg.statusPages[0])
+	// --timeout bounds the whole wait; individual requests keep the usual limit.
+	client.http.Timeout = defaultTimeout
+
+	result := runWait(ctx, client, cfg, os.Stderr)
+	if result.Err != nil {
+		fmt.Fprintln(os.Stderr, result.Err)
+		return exitUsage
+	}
+	if result.Interrupted {
+		return exitError
+	}
+	if err := renderWaitResult(os.Stdout, result, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if !result.Reached {
+		return exitTimedOut
+	}
+	return exitOK
+}
+
+func runIncidentCommand(args []string) int {
+	cfg, err := parseIncidentFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	client := newClient(cfg, cfg.statusPages[0])
+
+	id, err := client.ResolveIncidentID(ctx, cfg.incidentRef)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	inc, err := client.Incident(ctx, id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	tl := buildTimeline(inc, time.Now())
+	if cfg.output == outputJSON {
+		err = renderIncidentJSON(os.Stdout, tl)
+	} else {
+		renderIncidentText(os.Stdout, tl, cfg.style)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runHistoryCommand(args []string) int {
+	cfg, err := parseHistoryFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	client := newClient(cfg, cfg.statusPages[0])
+	incidents, refreshErr, err := loadHistory(ctx, client, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	now := time.Now()
+	timelines := queryHistory(incidents, cfg.window, cfg.filter, loadGroups(ctx, client, cfg), cfg.impacts, now)
+	if cfg.output == outputJSON {
+		err = renderHistoryJSON(os.Stdout, client.siteURL, timelines, cfg.window, refreshErr)
+	} else {
+		renderHistoryText(os.Stdout, timelines, cfg.window, refreshErr, now, cfg.style)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runStatsCommand(args []string) int {
+	cfg, err := parseStatsFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	client := newClient(cfg, cfg.statusPages[0])
+	incidents, refreshErr, err := loadHistory(ctx, client, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	components := loadComponents(ctx, client, cfg)
+
+	now := time.Now()
+	timelines := queryHistory(incidents, cfg.window, cfg.filter, newComponentGroups(components), cfg.impacts, now)
+	stats := computeStats(timelines, components, cfg.window, cfg.filter, now)
+	stats.RefreshErr = refreshErr
+	if cfg.output == outputJSON {
+		err = renderStatsJSON(os.Stdout, client.siteURL, stats)
+	} else {
+		err = renderStatsText(os.Stdout, stats, now, cfg.style)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+func runAtCommand(args []string) int {
+	cfg, err := parseAtFlags(args)
+	if err != nil {
+		return usageExit(err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	client := newClient(cfg, cfg.statusPages[0])
+	// Incidents that started before the range may have been resolved long
+	// after it, so fetch everything updated since its start.
+	fetch := cfg
+	fetch.window = timeWindow{Since: cfg.window.Since}
+	// Answering from history that may be out of date would wrongly report
+	// no overlaps, so a failed refresh is an error unless --offline is set.
+	incidents, refreshErr, err := loadHistory(ctx, client, fetch)
+	if err == nil {
+		err = refreshErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	overlaps := findOverlaps(incidents, cfg.window, cfg.filter, loadGroups(ctx, client, cfg), time.Now())
+	if cfg.output == outputJSON {
+		err = renderOverlapsJSON(os.Stdout, client.siteURL, overlaps, cfg.window)
+	} else {
+		renderOverlapsText(os.Stdout, overlaps, cfg.window, cfg.style)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return checkExitCode(overlapsHealth(overlaps), healthDegraded)
+}
+
+func newClient(cfg config, page string) *statusClient {
+	client := newStatusClient(page, cfg.timeout)
+	client.retry.attempts = cfg.retries + 1
+	if !cfg.noCache {
+		if dir, err := defaultCacheDir(); err == nil {
+			client.cache = newHTTPCache(dir, cfg.maxAge)
+		}
+	}
+	if dir, err := defaultHistoryDir(); err == nil {
+		client.history = newHistoryStore(dir)
+	}
+	return client
+}
+
+func newSnapshots() *snapshotStore {
+	dir, err := defaultSnapshotDir()
+	if err != nil {
+		return nil
 	}
+	return newSnapshotStore(dir)
 }
@@ -6,11 +6,16 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/cli/go-gh/v2/pkg/term"
 )
 
 func TestFormatStatus(t *testing.T) {
@@ -61,12 +66,163 @@ func TestParseFlags(t *testing.T) {
 		t.Fatalf("expected timeout error, got %v", err)
 	}
 
+	cfg, err = parseFlags([]string{"--status-page", "status.npmjs.org", "--status-page", "https://status.docker.com"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if len(cfg.statusPages) != 2 || cfg.statusPages[0] != "https://status.npmjs.org/" || cfg.statusPages[1] != "https://status.docker.com/" {
+		t.Fatalf("unexpected status pages: %q", cfg.statusPages)
+	}
+
+	if _, err = parseFlags([]string{"--status-page", "ftp://status.example"}); err == nil {
+		t.Fatal("expected error for non-http status page")
+	}
+
+	cfg, err = parseFlags([]string{"--component", "Actions", "--component", "git*", "--exclude", "Pages"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if len(cfg.filter.include) != 2 || len(cfg.filter.exclude) != 1 {
+		t.Fatalf("unexpected filter: %#v", cfg.filter)
+	}
+
 	_, err = parseFlags([]string{"--help"})
 	if !errors.Is(err, flag.ErrHelp) {
 		t.Fatalf("expected flag.ErrHelp, got %v", err)
 	}
 }
 
+func TestReportHealth(t *testing.T) {
+	rep := report{Components: []component{{Name: "API", Status: "operational"}}}
+	if h := reportHealth(rep); h != healthOperational {
+		t.Fatalf("expected operational, got %s", h)
+	}
+
+	rep.Components = append(rep.Components, component{Name: "Actions", Status: "degraded_performance"})
+	if h := reportHealth(rep); h != healthDegraded {
+		t.Fatalf("expected degraded, got %s", h)
+	}
+
+	rep.Active = []incident{{Name: "Git outage", Impact: "major"}}
+	if h := reportHealth(rep); h != healthPartialOutage {
+		t.Fatalf("expected partial_outage, got %s", h)
+	}
+
+	rep.Components = append(rep.Components, component{Name: "Git Operations", Status: "major_outage"})
+	if h := reportHealth(rep); h != healthMajorOutage {
+		t.Fatalf("expected major_outage, got %s", h)
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	cases := []struct {
+		health    health
+		threshold health
+		want      int
+	}{
+		{healthOperational, healthDegraded, exitOK},
+		{healthDegraded, healthDegraded, exitDegraded},
+		{healthDegraded, healthPartialOutage, exitOK},
+		{healthPartialOutage, healthDegraded, exitPartialOutage},
+		{healthMajorOutage, healthMajorOutage, exitMajorOutage},
+	}
+	for _, tc := range cases {
+		if got := checkExitCode(tc.health, tc.threshold); got != tc.want {
+			t.Fatalf("checkExitCode(%s, %s) = %d, want %d", tc.health, tc.threshold, got, tc.want)
+		}
+	}
+
+	cfg, err := parseFlags([]string{"--check", "--fail-on", "partial-outage"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if !cfg.check || cfg.failOn != healthPartialOutage {
+		t.Fatalf("unexpected config: %#v", cfg)
+	}
+	if _, err := parseFlags([]string{"--fail-on", "operational"}); err == nil {
+		t.Fatal("expected error for operational threshold")
+	}
+	if _, err := parseFlags([]string{"--fail-on", "broken"}); err == nil {
+		t.Fatal("expected error for unknown threshold")
+	}
+}
+
+func TestRunDispatchesCommands(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		want int
+	}{
+		"unknown command":       {[]string{"bogus"}, exitUsage},
+		"legacy flag help":      {[]string{"--help"}, exitOK},
+		"command help":          {[]string{"wait", "--help"}, exitOK},
+		"help subcommand":       {[]string{"help", "incident"}, exitOK},
+		"help unknown command":  {[]string{"help", "bogus"}, exitUsage},
+		"missing incident id":   {[]string{"incident"}, exitUsage},
+		"watch with bad option": {[]string{"watch", "--interval", "0s"}, exitUsage},
+		"watch as table":        {[]string{"--watch", "--format", "table"}, exitUsage},
+		"watch as markdown":     {[]string{"--watch", "--format", "markdown"}, exitUsage},
+	}
+	for name, tc := range cases {
+		if got := run(tc.args); got != tc.want {
+			t.Fatalf("%s: run(%q) = %d, want %d", name, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestParseCommandFlags(t *testing.T) {
+	cfg, err := parseFlags([]string{"--details", "--resolved", "--json"})
+	if err != nil {
+		t.Fatalf("legacy flags returned error: %v", err)
+	}
+	if !cfg.showDetails || !cfg.showResolved || cfg.output != outputJSON {
+		t.Fatalf("unexpected legacy config: %#v", cfg)
+	}
+
+	cfg, err = parseWatchFlags([]string{"--interval", "1m", "--component", "Actions", "--json"})
+	if err != nil {
+		t.Fatalf("parseWatchFlags returned error: %v", err)
+	}
+	if !cfg.watch || cfg.interval != time.Minute || cfg.output != outputJSON || len(cfg.filter.include) != 1 {
+		t.Fatalf("unexpected watch config: %#v", cfg)
+	}
+
+	if _, err := parseWatchFlags([]string{"--status-page", "a.example", "--status-page", "b.example"}); err == nil {
+		t.Fatal("expected watch to reject multiple status pages")
+	}
+
+	cfg, err = parseIncidentFlags([]string{"--timeout", "30s", "abc"})
+	if err != nil || cfg.timeout != 30*time.Second || cfg.incidentRef != "abc" {
+		t.Fatalf("unexpected incident config: %#v, %v", cfg, err)
+	}
+}
+
+func TestStatusPagesFromEnv(t *testing.T) {
+	t.Setenv(statusPagesEnv, "status.npmjs.org, https://status.docker.com")
+
+	cfg, err := parseFlags(nil)
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if len(cfg.statusPages) != 2 || cfg.statusPages[0] != "https://status.npmjs.org/" || cfg.statusPages[1] != "https://status.docker.com/" {
+		t.Fatalf("unexpected status pages: %q", cfg.statusPages)
+	}
+
+	cfg, err = parseFlags([]string{"--status-page", "status.example.com"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if len(cfg.statusPages) != 1 || cfg.statusPages[0] != "https://status.example.com/" {
+		t.Fatalf("--status-page should override $%s: %q", statusPagesEnv, cfg.statusPages)
+	}
+
+	if _, err := parseWaitFlags(nil); err == nil || !strings.Contains(err.Error(), statusPagesEnv) {
+		t.Fatalf("expected wait to reject several configured pages, got %v", err)
+	}
+	if _, err := parseWaitFlags([]string{"--status-page", "status.example.com"}); err != nil {
+		t.Fatalf("parseWaitFlags returned error: %v", err)
+	}
+}
+
 func TestRenderText(t *testing.T) {
 	buf := &bytes.Buffer{}
 
@@ -99,6 +255,64 @@ func TestRenderText(t *testing.T) {
 	if !strings.Contains(out, "Active incidents:") || !strings.Contains(out, "Codespaces degraded") {
 		t.Fatalf("missing incidents section:\n%s", out)
 	}
+
+	buf.Reset()
+	rep.StatusPage = "https://status.npmjs.org/"
+	renderText(buf, rep, config{})
+	out = buf.String()
+	if !strings.HasPrefix(out, "status.npmjs.org Service Status - ") {
+		t.Fatalf("expected page host in header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "See full incident history: https://status.npmjs.org/") {
+		t.Fatalf("expected page link, got:\n%s", out)
+	}
+}
+
+func TestStyle(t *testing.T) {
+	env := term.FromEnv()
+
+	st, err := newStyle(colorNever, iconsASCII, env)
+	if err != nil {
+		t.Fatalf("newStyle returned error: %v", err)
+	}
+	if got := st.icon("major_outage"); got != "[DOWN] " {
+		t.Fatalf("expected [DOWN] marker, got %q", got)
+	}
+	if got := st.icon("degraded_performance"); got != "[DEGRADED] " {
+		t.Fatalf("expected [DEGRADED] marker, got %q", got)
+	}
+
+	st, err = newStyle(colorAlways, iconsNone, env)
+	if err != nil {
+		t.Fatalf("newStyle returned error: %v", err)
+	}
+	if got := st.icon("operational"); got != "" {
+		t.Fatalf("expected no icon, got %q", got)
+	}
+	if got := st.status("major_outage"); got != "\033[31mMajor Outage\033[0m" {
+		t.Fatalf("expected red status, got %q", got)
+	}
+
+	if _, err := newStyle("sometimes", iconsEmoji, env); err == nil {
+		t.Fatal("expected error for invalid color mode")
+	}
+	if _, err := newStyle(colorAuto, "unicode", env); err == nil {
+		t.Fatal("expected error for invalid icons")
+	}
+
+	buf := &bytes.Buffer{}
+	rep := report{
+		Components: []component{{Name: "API Requests", Status: "operational"}},
+		Stale:      true,
+	}
+	renderText(buf, rep, config{style: style{icons: iconsASCII}})
+	out := buf.String()
+	if !strings.Contains(out, "[OK] API Requests - Operational") || !strings.Contains(out, "[!] Showing saved snapshot") {
+		t.Fatalf("expected ascii markers, got:\n%s", out)
+	}
+	if strings.Contains(out, "\033[") {
+		t.Fatalf("expected no color codes, got:\n%s", out)
+	}
 }
 
 func TestRenderJSON(t *testing.T) {
@@ -118,7 +332,7 @@ func TestRenderJSON(t *testing.T) {
 		},
 	}
 
-	if err := renderJSON(buf, rep); err != nil {
+	if err := renderJSON(buf, rep, config{}); err != nil {
 		t.Fatalf("renderJSON returned error: %v", err)
 	}
 
@@ -139,17 +353,15 @@ func TestBuildReport(t *testing.T) {
 	server := newStatusServer()
 	defer server.Close()
 
-	client := newStatusClient(5 * time.Second)
-	client.http = server.Client()
-	client.componentsURL = server.URL + "/components.json"
-	client.unresolvedURL = server.URL + "/incidents/unresolved.json"
-	client.incidentsURL = server.URL + "/incidents.json"
+	client := newTestClient(server)
 
 	cfg := config{
-		showDetails:  true,
-		showResolved: true,
-		output:       outputText,
-		timeout:      5 * time.Second,
+		showDetails:     true,
+		showResolved:    true,
+		showMaintenance: true,
+		output:          outputText,
+		timeout:         5 * time.Second,
+		window:          timeWindow{Since: time.Now().Add(-defaultHistoryWindow)},
 	}
 
 	rep, err := buildReport(context.Background(), client, cfg)
@@ -160,6 +372,9 @@ func TestBuildReport(t *testing.T) {
 	if len(rep.Components) != 2 {
 		t.Fatalf("expected 2 components, got %d", len(rep.Components))
 	}
+	if rep.StatusPage != server.URL+"/" {
+		t.Fatalf("unexpected status page: %q", rep.StatusPage)
+	}
 	if len(rep.Active) != 1 {
 		t.Fatalf("expected 1 active incident, got %d", len(rep.Active))
 	}
@@ -169,74 +384,1640 @@ func TestBuildReport(t *testing.T) {
 	if rep.Resolved[0].Name != "Recent Incident" {
 		t.Fatalf("unexpected resolved incident: %#v", rep.Resolved[0])
 	}
+	if len(rep.Maintenances) != 2 || rep.Maintenances[0].Name != "Database upgrade" {
+		t.Fatalf("unexpected maintenances: %#v", rep.Maintenances)
+	}
 }
 
-func newStatusServer() *httptest.Server {
+func TestBuildReportPrefersSummary(t *testing.T) {
+	var fallbackHits int
 	mux := http.NewServeMux()
-
-	mux.HandleFunc("/components.json", func(w http.ResponseWriter, r *http.Request) {
-		payload := statusResponse{
+	mux.HandleFunc("/api/v2/summary.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := summaryResponse{
+			Page:   page{Name: "GitHub", URL: "https://www.githubstatus.com"},
+			Status: pageStatus{Indicator: "minor", Description: "Partially Degraded Service"},
 			Components: []component{
-				{Name: "API Requests", Status: "operational", Group: false},
-				{Name: "Codespaces", Status: "major_outage", Group: false},
-				{Name: referenceComponent, Status: "operational", Group: false},
-				{Name: "Group Container", Status: "operational", Group: true},
+				{Name: "Actions", Status: "degraded_performance"},
+				{Name: referenceComponent, Status: "operational"},
+			},
+			Incidents: []incident{
+				{ID: "active-1", Name: "Actions delays", Status: "investigating", Impact: "minor"},
 			},
 		}
 		json.NewEncoder(w).Encode(payload)
 	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fallbackHits++
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-	now := time.Now().UTC()
-	recent := now.Add(-24 * time.Hour)
-	old := now.Add(-10 * 24 * time.Hour)
+	client := newTestClient(server)
 
-	mux.HandleFunc("/incidents/unresolved.json", func(w http.ResponseWriter, r *http.Request) {
-		payload := incidentResponse{
-			Incidents: []incident{
-				{
-					ID:        "active-1",
-					Name:      "Active Incident",
-					Status:    "investigating",
-					Impact:    "major",
-					UpdatedAt: recent.Format(time.RFC3339),
-					IncidentUpdates: []incidentUpdate{
-						{Status: "investigating", Body: "Investigating", CreatedAt: recent.Format(time.RFC3339)},
-					},
-				},
-			},
+	rep, err := buildReport(context.Background(), client, config{showDetails: true, output: outputText})
+	if err != nil {
+		t.Fatalf("buildReport returned error: %v", err)
+	}
+
+	if fallbackHits != 0 {
+		t.Fatalf("expected no per-endpoint requests, got %d", fallbackHits)
+	}
+	if rep.Status.Indicator != "minor" || rep.Page.Name != "GitHub" {
+		t.Fatalf("unexpected page status: %#v %#v", rep.Page, rep.Status)
+	}
+	if len(rep.Components) != 1 || len(rep.Active) != 1 {
+		t.Fatalf("unexpected report: %#v", rep)
+	}
+}
+
+func TestBuildReportCancelsOnFirstError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/summary.json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
 		}
-		json.NewEncoder(w).Encode(payload)
 	})
+	mux.HandleFunc("/api/v2/incidents.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
 
-	mux.HandleFunc("/incidents.json", func(w http.ResponseWriter, r *http.Request) {
-		payload := incidentResponse{
-			Incidents: []incident{
-				{
-					ID:        "resolved-new",
-					Name:      "Recent Incident",
-					Status:    "resolved",
-					Impact:    "major",
-					UpdatedAt: recent.Format(time.RFC3339),
-					IncidentUpdates: []incidentUpdate{
-						{Status: "resolved", Body: "Fixed", CreatedAt: recent.Format(time.RFC3339)},
-					},
-				},
-				{
-					ID:        "resolved-old",
-					Name:      "Old Incident",
-					Status:    "resolved",
-					Impact:    "major",
-					UpdatedAt: old.Format(time.RFC3339),
-					IncidentUpdates: []incidentUpdate{
-						{Status: "resolved", Body: "Old fix", CreatedAt: old.Format(time.RFC3339)},
-					},
-				},
-				{
-					ID:        "monitoring",
-					Name:      "Monitoring Incident",
-					Status:    "monitoring",
-					Impact:    "minor",
-					UpdatedAt: recent.Format(time.RFC3339),
+	start := time.Now()
+	_, err := buildReport(context.Background(), newTestClient(server), config{showResolved: true, output: outputText})
+	if err == nil || !strings.Contains(err.Error(), "resolved incidents") {
+		t.Fatalf("expected resolved incidents error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected pending fetches to be cancelled, took %s", elapsed)
+	}
+}
+
+func TestClientRetriesTransientFailures(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode(statusResponse{Components: []component{{Name: "API", Status: "operational"}}})
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	comps, err := client.Components(context.Background())
+	if err != nil {
+		t.Fatalf("Components returned error: %v", err)
+	}
+	if calls != 3 || len(comps) != 1 {
+		t.Fatalf("expected success on third attempt, got %d calls and %#v", calls, comps)
+	}
+}
+
+func TestClientDoesNotRetryPermanentFailures(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := newTestClient(server).Components(context.Background()); err == nil {
+		t.Fatal("expected error for 404 response")
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestClientRetryRespectsDeadline(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newTestClient(server).Components(ctx)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected 503 error, got %v", err)
+	}
+	if calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected to give up without waiting past the deadline, got %d calls in %s", calls, time.Since(start))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"Fri, 16 Oct 2026 12:00:30 GMT": 30 * time.Second,
+		"Fri, 16 Oct 2026 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for input, want := range cases {
+		if got := parseRetryAfter(input, now); got != want {
+			t.Fatalf("parseRetryAfter(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestClientRevalidatesCachedResponses(t *testing.T) {
+	var calls, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=0")
+		json.NewEncoder(w).Encode(statusResponse{Components: []component{{Name: "API", Status: "operational"}}})
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.cache = newHTTPCache(t.TempDir(), 0)
+
+	for i := 0; i < 2; i++ {
+		comps, err := client.Components(context.Background())
+		if err != nil {
+			t.Fatalf("Components returned error: %v", err)
+		}
+		if len(comps) != 1 || comps[0].Name != "API" {
+			t.Fatalf("unexpected components on run %d: %#v", i, comps)
+		}
+	}
+
+	if calls != 2 || notModified != 1 {
+		t.Fatalf("expected one full and one conditional request, got %d calls and %d 304s", calls, notModified)
+	}
+}
+
+func TestClientServesFreshCacheWithoutRequest(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		json.NewEncoder(w).Encode(statusResponse{Components: []component{{Name: "API", Status: "operational"}}})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := newTestClient(server)
+	client.cache = newHTTPCache(dir, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Components(context.Background()); err != nil {
+			t.Fatalf("Components returned error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single request while cached response is fresh, got %d", calls)
+	}
+
+	client.cache = nil
+	if _, err := client.Components(context.Background()); err != nil {
+		t.Fatalf("Components returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected uncached client to hit the network, got %d calls", calls)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":                         0,
+		"max-age=60":               time.Minute,
+		"public, max-age=30":       30 * time.Second,
+		"no-cache, max-age=60":     0,
+		`max-age="10", must-reval`: 10 * time.Second,
+	}
+	for input, want := range cases {
+		if got := cacheMaxAge(input); got != want {
+			t.Fatalf("cacheMaxAge(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestFetchReportFallsBackToSnapshot(t *testing.T) {
+	server := newStatusServer()
+	client := newTestClient(server)
+	snapshots := newSnapshotStore(t.TempDir())
+	cfg := config{showDetails: true, output: outputText}
+
+	if _, err := fetchReport(context.Background(), client, snapshots, cfg); err != nil {
+		t.Fatalf("fetchReport returned error: %v", err)
+	}
+
+	offline, err := fetchReport(context.Background(), client, snapshots, config{offline: true})
+	if err != nil {
+		t.Fatalf("offline fetchReport returned error: %v", err)
+	}
+	if !offline.Stale || offline.FetchErr != "" || len(offline.Components) != 2 {
+		t.Fatalf("unexpected offline report: %#v", offline)
+	}
+
+	server.Close()
+
+	rep, err := fetchReport(context.Background(), client, snapshots, cfg)
+	if err != nil {
+		t.Fatalf("expected snapshot fallback, got error: %v", err)
+	}
+	if !rep.Stale || rep.FetchErr == "" || rep.FetchedAt.IsZero() || len(rep.Active) != 1 {
+		t.Fatalf("unexpected stale report: %#v", rep)
+	}
+
+	buf := &bytes.Buffer{}
+	renderText(buf, rep, cfg)
+	if !strings.Contains(buf.String(), "Showing saved snapshot from") {
+		t.Fatalf("expected stale warning:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := renderJSON(buf, rep, config{}); err != nil {
+		t.Fatalf("renderJSON returned error: %v", err)
+	}
+	var payload jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v", err)
+	}
+	if !payload.Stale || payload.FetchedAt == "" {
+		t.Fatalf("expected stale JSON report: %#v", payload)
+	}
+
+	if _, err := fetchReport(context.Background(), client, newSnapshotStore(t.TempDir()), config{offline: true}); err == nil {
+		t.Fatal("expected error when no snapshot exists")
+	}
+}
+
+func TestSnapshotIsReshapedForCurrentFlags(t *testing.T) {
+	server := newStatusServer()
+	defer server.Close()
+	client := newTestClient(server)
+	snapshots := newSnapshotStore(t.TempDir())
+
+	filtered := config{output: outputText, filter: componentFilter{include: []string{"API Requests"}}}
+	if _, err := fetchReport(context.Background(), client, snapshots, filtered); err != nil {
+		t.Fatalf("fetchReport returned error: %v", err)
+	}
+
+	rep, err := fetchReport(context.Background(), client, snapshots, config{offline: true, check: true})
+	if err != nil {
+		t.Fatalf("offline fetchReport returned error: %v", err)
+	}
+	if len(rep.Components) != 2 || reportHealth(rep) != healthMajorOutage {
+		t.Fatalf("snapshot kept the earlier filter: %#v", rep.Components)
+	}
+
+	rep, err = fetchReport(context.Background(), client, snapshots, config{offline: true, showResolved: true})
+	if err != nil {
+		t.Fatalf("offline fetchReport returned error: %v", err)
+	}
+	if !rep.missing(sectionResolved) {
+		t.Fatalf("expected resolved incidents to be reported missing: %#v", rep.Missing)
+	}
+	buf := &bytes.Buffer{}
+	renderText(buf, rep, config{showResolved: true})
+	if !strings.Contains(buf.String(), "were not fetched when this snapshot was saved") || strings.Contains(buf.String(), "No resolved incidents") {
+		t.Fatalf("expected missing section notice:\n%s", buf.String())
+	}
+
+	if _, err := fetchReport(context.Background(), client, snapshots, config{output: outputText, showResolved: true}); err != nil {
+		t.Fatalf("fetchReport returned error: %v", err)
+	}
+	rep, err = fetchReport(context.Background(), client, snapshots, config{offline: true, showResolved: true, filter: componentFilter{exclude: []string{"Codespaces"}}})
+	if err != nil {
+		t.Fatalf("offline fetchReport returned error: %v", err)
+	}
+	if len(rep.Missing) != 0 || len(rep.Resolved) == 0 || len(rep.Components) != 1 {
+		t.Fatalf("unexpected reshaped snapshot: %#v", rep)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	cases := map[time.Duration]string{
+		30 * time.Second:              "less than a minute",
+		5 * time.Minute:               "5m",
+		2*time.Hour + 5*time.Minute:   "2h 5m",
+		3 * time.Hour:                 "3h",
+		50 * time.Hour:                "2d 2h",
+		48*time.Hour + 20*time.Minute: "2d",
+	}
+	for input, want := range cases {
+		if got := formatAge(input); got != want {
+			t.Fatalf("formatAge(%s) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestBuildProviderReports(t *testing.T) {
+	server := newStatusServer()
+	defer server.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	brokenClient := newTestClient(broken)
+
+	providers := buildProviderReports(context.Background(), []*statusClient{newTestClient(server), brokenClient}, nil, config{output: outputJSON})
+
+	if len(providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(providers))
+	}
+	if providers[0].Err != nil || len(providers[0].Components) != 2 {
+		t.Fatalf("unexpected first provider: %#v", providers[0])
+	}
+	if providers[1].Err == nil || providers[1].StatusPage != broken.URL+"/" {
+		t.Fatalf("expected error for second provider: %#v", providers[1])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := renderProvidersJSON(buf, providers, config{}); err != nil {
+		t.Fatalf("renderProvidersJSON returned error: %v", err)
+	}
+	var payload jsonProviders
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v\n%s", err, buf.String())
+	}
+	if len(payload.Providers) != 2 || payload.Providers[0].Error != "" || payload.Providers[1].Error == "" {
+		t.Fatalf("unexpected providers: %#v", payload.Providers)
+	}
+
+	buf.Reset()
+	renderProvidersText(buf, providers, config{})
+	if !strings.Contains(buf.String(), "Service Status - unavailable") {
+		t.Fatalf("expected unavailable provider section:\n%s", buf.String())
+	}
+}
+
+func TestComponentTree(t *testing.T) {
+	all := []component{
+		{ID: "grp", Name: "Actions", Status: "degraded_performance", Group: true, Position: 2},
+		{ID: "runners", Name: "Hosted Runners", Status: "degraded_performance", GroupID: "grp", Position: 2},
+		{ID: "cache", Name: "Actions Cache", Status: "operational", GroupID: "grp", Position: 1},
+		{ID: "git", Name: "Git Operations", Status: "operational", Position: 1},
+		{ID: "empty", Name: "Empty Group", Status: "operational", Group: true, Position: 3},
+	}
+	leaves := filterComponents(all, componentFilter{})
+	if len(leaves) != 3 {
+		t.Fatalf("expected 3 leaf components, got %#v", leaves)
+	}
+
+	tree := buildComponentTree(all, leaves, sortByPosition)
+	if len(tree) != 2 || tree[0].Name != "Git Operations" || tree[1].Name != "Actions" {
+		t.Fatalf("unexpected tree roots: %#v", tree)
+	}
+	if len(tree[1].Children) != 2 || tree[1].Children[0].Name != "Actions Cache" {
+		t.Fatalf("unexpected group children: %#v", tree[1].Children)
+	}
+
+	tree = buildComponentTree(all, leaves, sortByName)
+	if tree[0].Name != "Actions" || tree[0].Children[0].Name != "Actions Cache" {
+		t.Fatalf("unexpected alphabetical tree: %#v", tree)
+	}
+
+	rep := report{Components: leaves, Tree: tree}
+	buf := &bytes.Buffer{}
+	renderText(buf, rep, config{})
+	if !strings.Contains(buf.String(), "🟡 Actions - Degraded Performance\n  🟢 Actions Cache - Operational\n") {
+		t.Fatalf("expected indented hierarchy:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	renderText(buf, rep, config{flat: true})
+	if strings.Contains(buf.String(), "  🟢 Actions Cache") || strings.Contains(buf.String(), "Actions - ") {
+		t.Fatalf("expected flat component list:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := renderJSON(buf, rep, config{}); err != nil {
+		t.Fatalf("renderJSON returned error: %v", err)
+	}
+	var payload jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v", err)
+	}
+	if len(payload.Components) != 2 || len(payload.Components[0].Children) != 2 || !payload.Components[0].Group {
+		t.Fatalf("expected nested JSON components: %#v", payload.Components)
+	}
+	if payload.Components[0].Children[0].GroupID != "grp" || payload.Components[1].GroupID != "" {
+		t.Fatalf("expected group IDs on grouped components only: %#v", payload.Components)
+	}
+
+	buf.Reset()
+	if err := renderJSON(buf, rep, config{flat: true}); err != nil {
+		t.Fatalf("renderJSON returned error: %v", err)
+	}
+	payload = jsonReport{}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v", err)
+	}
+	for _, comp := range payload.Components {
+		if want := map[string]string{"runners": "grp", "cache": "grp"}[comp.ID]; comp.GroupID != want {
+			t.Fatalf("expected flat component %s in group %q, got %q", comp.ID, want, comp.GroupID)
+		}
+	}
+}
+
+func TestComponentFilter(t *testing.T) {
+	all := []component{
+		{ID: "actions", Name: "Actions"},
+		{ID: "packages", Name: "Packages"},
+		{ID: "git", Name: "Git Operations"},
+		{ID: "pages", Name: "Pages"},
+		{ID: "copilot", Name: "Copilot"},
+	}
+
+	filter := componentFilter{include: []string{"actions", "git *", "pa*"}, exclude: []string{"PAGES"}}
+	if err := filter.validate(); err != nil {
+		t.Fatalf("validate returned error: %v", err)
+	}
+
+	got := filterComponents(all, filter)
+	names := make([]string, 0, len(got))
+	for _, comp := range got {
+		names = append(names, comp.Name)
+	}
+	if strings.Join(names, ",") != "Actions,Git Operations,Packages" {
+		t.Fatalf("unexpected filtered components: %v", names)
+	}
+
+	incidents := []incident{
+		{ID: "1", Name: "Actions delays", Components: []component{{ID: "actions", Name: "Actions"}}},
+		{ID: "2", Name: "Copilot errors", Components: []component{{ID: "copilot", Name: "Copilot"}}},
+		{ID: "3", Name: "Unscoped incident"},
+	}
+	kept := filterIncidents(incidents, got, filter)
+	if len(kept) != 1 || kept[0].ID != "1" {
+		t.Fatalf("unexpected filtered incidents: %#v", kept)
+	}
+
+	kept = filterIncidents(incidents, filterComponents(all, componentFilter{exclude: []string{"copilot"}}), componentFilter{exclude: []string{"copilot"}})
+	if len(kept) != 2 || kept[0].ID != "1" || kept[1].ID != "3" {
+		t.Fatalf("unexpected incidents for exclude-only filter: %#v", kept)
+	}
+
+	if err := (componentFilter{include: []string{"[actions"}}).validate(); err == nil {
+		t.Fatal("expected error for malformed pattern")
+	}
+}
+
+func TestComponentFilterMatchesGroups(t *testing.T) {
+	all := []component{
+		{ID: "grp", Name: "Actions", Group: true},
+		{ID: "runners", Name: "Hosted Runners", GroupID: "grp"},
+		{ID: "git", Name: "Git Operations"},
+	}
+	got := filterComponents(all, componentFilter{include: []string{"actions"}})
+	if len(got) != 1 || got[0].Name != "Hosted Runners" {
+		t.Fatalf("expected group members to match the group name: %#v", got)
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	prev := report{
+		Components: []component{{ID: "git", Name: "Git Operations", Status: "operational"}},
+		Active: []incident{
+			{ID: "old", Name: "Old incident", Status: "monitoring"},
+			{ID: "ongoing", Name: "Actions delays", Status: "investigating", IncidentUpdates: []incidentUpdate{
+				{Status: "investigating", Body: "Looking into it", CreatedAt: "2026-10-16T10:00:00Z"},
+			}},
+		},
+	}
+	next := report{
+		Components: []component{{ID: "git", Name: "Git Operations", Status: "major_outage"}},
+		Active: []incident{
+			{ID: "ongoing", Name: "Actions delays", Status: "identified", IncidentUpdates: []incidentUpdate{
+				{Status: "identified", Body: "Found the cause", CreatedAt: "2026-10-16T10:30:00Z"},
+				{Status: "investigating", Body: "Looking into it", CreatedAt: "2026-10-16T10:00:00Z"},
+			}},
+			{ID: "new", Name: "Git unavailable", Status: "investigating", Impact: "critical"},
+		},
+	}
+
+	changes := diffReports(prev, next, time.Now(), style{})
+	kinds := make([]string, 0, len(changes))
+	for _, c := range changes {
+		kinds = append(kinds, c.Kind)
+	}
+	want := []string{changeComponent, changeIncidentUpdate, changeIncidentNew, changeIncidentResolved}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected change kinds %v, want %v", kinds, want)
+	}
+	if !strings.Contains(changes[0].Text, "Git Operations: Operational → Major Outage") {
+		t.Fatalf("unexpected component change: %q", changes[0].Text)
+	}
+	if !strings.Contains(changes[1].Text, "Found the cause") || !strings.Contains(changes[3].Text, "Resolved: Old incident") {
+		t.Fatalf("unexpected incident changes: %#v", changes)
+	}
+
+	if len(diffReports(next, next, time.Now(), style{})) != 0 {
+		t.Fatal("expected no changes between identical reports")
+	}
+}
+
+func TestRunWatchStopsOnCancel(t *testing.T) {
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/summary.json", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := "operational"
+		if polls > 1 {
+			status = "partial_outage"
+		}
+		json.NewEncoder(w).Encode(summaryResponse{Components: []component{{ID: "api", Name: "API", Status: status}}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() {
+		done <- runWatch(ctx, newTestClient(server), config{interval: 10 * time.Millisecond, timeout: time.Second, output: outputText}, buf, false)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("runWatch returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("runWatch did not stop after cancellation")
+	}
+
+	out := buf.String()
+	if strings.Count(out, "Service Status - ") != 1 {
+		t.Fatalf("expected a single summary on a non-terminal:\n%s", out)
+	}
+	if strings.Count(out, "API: Operational → Partial Outage") != 1 {
+		t.Fatalf("expected one transition line:\n%s", out)
+	}
+}
+
+func TestRunWait(t *testing.T) {
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/summary.json", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := "major_outage"
+		if polls >= 3 {
+			status = "operational"
+		}
+		json.NewEncoder(w).Encode(summaryResponse{Components: []component{
+			{Name: "Actions", Status: status},
+			{Name: "Pages", Status: "major_outage"},
+		}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := config{
+		until:    "operational",
+		interval: 5 * time.Millisecond,
+		filter:   componentFilter{include: []string{"actions"}},
+	}
+
+	progress := &bytes.Buffer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	result := runWait(ctx, newTestClient(server), cfg, progress)
+	if !result.Reached || polls != 3 {
+		t.Fatalf("expected to reach target on third poll, got %#v after %d polls", result, polls)
+	}
+	if strings.Count(progress.String(), "Waiting for 1 component(s) to reach Operational") != 2 {
+		t.Fatalf("unexpected progress output:\n%s", progress.String())
+	}
+
+	out := &bytes.Buffer{}
+	if err := renderWaitResult(out, result, config{output: outputJSON}); err != nil {
+		t.Fatalf("renderWaitResult returned error: %v", err)
+	}
+	var payload jsonWaitResult
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v", err)
+	}
+	if !payload.Reached || payload.Target != "operational" || len(payload.Components) != 1 {
+		t.Fatalf("unexpected JSON result: %#v", payload)
+	}
+
+	cfg.filter = componentFilter{include: []string{"pages"}}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if result := runWait(ctx, newTestClient(server), cfg, io.Discard); result.Reached || result.Interrupted {
+		t.Fatalf("expected wait to time out, got %#v", result)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if result := runWait(ctx, newTestClient(server), cfg, io.Discard); result.Reached || !result.Interrupted {
+		t.Fatalf("expected cancelling to interrupt the wait, got %#v", result)
+	}
+
+	cfg.filter = componentFilter{include: []string{"Actons"}}
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	polls = 0
+	result = runWait(ctx, newTestClient(server), cfg, io.Discard)
+	var noMatch *noMatchError
+	if !errors.As(result.Err, &noMatch) || result.Reached || polls != 1 {
+		t.Fatalf("expected an unmatched filter to stop after one poll, got %#v after %d polls", result, polls)
+	}
+}
+
+func TestPendingComponents(t *testing.T) {
+	components := []component{
+		{Name: "A", Status: "operational"},
+		{Name: "B", Status: "under_maintenance"},
+		{Name: "C", Status: "partial_outage"},
+	}
+	if got := pendingComponents(components, "operational"); len(got) != 2 {
+		t.Fatalf("expected 2 pending for operational, got %#v", got)
+	}
+	if got := pendingComponents(components, "degraded_performance"); len(got) != 1 || got[0].Name != "C" {
+		t.Fatalf("expected only C pending for degraded_performance, got %#v", got)
+	}
+}
+
+func TestParseWaitFlags(t *testing.T) {
+	cfg, err := parseWaitFlags([]string{"--component", "Actions", "--until", "degraded-performance", "--timeout", "2h", "--json"})
+	if err != nil {
+		t.Fatalf("parseWaitFlags returned error: %v", err)
+	}
+	if cfg.until != "degraded_performance" || cfg.timeout != 2*time.Hour || cfg.output != outputJSON || len(cfg.filter.include) != 1 {
+		t.Fatalf("unexpected config: %#v", cfg)
+	}
+	if _, err := parseWaitFlags([]string{"--until", "fixed"}); err == nil {
+		t.Fatal("expected error for unknown target status")
+	}
+}
+
+func TestIncidentCommandTimeline(t *testing.T) {
+	start := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/incidents/abc123.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(singleIncidentResponse{Incident: incident{
+			ID:         "abc123",
+			Name:       "Actions delays",
+			Status:     "postmortem",
+			Impact:     "major",
+			CreatedAt:  start.Format(time.RFC3339),
+			Components: []component{{Name: "Actions"}},
+			IncidentUpdates: []incidentUpdate{
+				{Status: "postmortem", Body: "Write-up", CreatedAt: start.Add(48 * time.Hour).Format(time.RFC3339)},
+				{Status: "resolved", Body: "Fixed", CreatedAt: start.Add(150 * time.Minute).Format(time.RFC3339)},
+				{Status: "identified", Body: "Found it", CreatedAt: start.Add(45 * time.Minute).Format(time.RFC3339)},
+				{Status: "investigating", Body: "Looking", CreatedAt: start.Format(time.RFC3339), AffectedComponents: []affectedComponent{
+					{Name: "Actions", OldStatus: "operational", NewStatus: "partial_outage"},
+				}},
+			},
+		}})
+	})
+	mux.HandleFunc("/s/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/incidents/abc123", http.StatusFound)
+	})
+	mux.HandleFunc("/incidents/abc123", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(server)
+	for _, ref := range []string{"abc123", server.URL + "/incidents/abc123", server.URL + "/s/short"} {
+		id, err := client.ResolveIncidentID(context.Background(), ref)
+		if err != nil || id != "abc123" {
+			t.Fatalf("ResolveIncidentID(%q) = %q, %v", ref, id, err)
+		}
+	}
+	if _, err := client.ResolveIncidentID(context.Background(), "https://status.example.org/incidents/abc123"); err == nil || !strings.Contains(err.Error(), "--status-page status.example.org") {
+		t.Fatalf("expected an incident on another status page to be rejected, got %v", err)
+	}
+
+	inc, err := client.Incident(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Incident returned error: %v", err)
+	}
+	tl := buildTimeline(inc, time.Now())
+	if tl.Ongoing || tl.Duration() != 150*time.Minute || len(tl.Entries) != 4 {
+		t.Fatalf("unexpected timeline: %#v", tl)
+	}
+	if tl.Entries[0].Update.Status != "investigating" || tl.Entries[1].SincePrevious != 45*time.Minute {
+		t.Fatalf("expected chronological entries: %#v", tl.Entries)
+	}
+
+	buf := &bytes.Buffer{}
+	renderIncidentText(buf, tl, style{})
+	out := buf.String()
+	for _, want := range []string{"ID: abc123", "Duration: 2h 30m", "Affected components: Actions", "(45m later) Identified: Found it", "Actions: Operational → Partial Outage"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := renderIncidentJSON(buf, tl); err != nil {
+		t.Fatalf("renderIncidentJSON returned error: %v", err)
+	}
+	var payload jsonIncidentDetail
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v", err)
+	}
+	if payload.DurationSeconds != 9000 || len(payload.Timeline) != 4 || len(payload.Timeline[0].AffectedComponents) != 1 {
+		t.Fatalf("unexpected JSON: %#v", payload)
+	}
+
+	if _, err := client.Incident(context.Background(), "missing"); err == nil {
+		t.Fatal("expected error for unknown incident")
+	}
+}
+
+func TestParseIncidentFlags(t *testing.T) {
+	cfg, err := parseIncidentFlags([]string{"abc123", "--json"})
+	if err != nil {
+		t.Fatalf("parseIncidentFlags returned error: %v", err)
+	}
+	if cfg.incidentRef != "abc123" || cfg.output != outputJSON {
+		t.Fatalf("unexpected config: %#v", cfg)
+	}
+	if _, err := parseIncidentFlags(nil); err == nil {
+		t.Fatal("expected error without an incident reference")
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"72h", now.Add(-72 * time.Hour)},
+		{"30d", now.Add(-30 * 24 * time.Hour)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+		{"2d ago", now.Add(-2 * 24 * time.Hour)},
+		{"2 d ago", now.Add(-2 * 24 * time.Hour)},
+		{"2 days ago", now.Add(-2 * 24 * time.Hour)},
+		{"1 week", now.Add(-7 * 24 * time.Hour)},
+		{"90 Minutes Ago", now.Add(-90 * time.Minute)},
+		{"1.5 hours ago", now.Add(-90 * time.Minute)},
+		{"2026-09-01", time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-09-01T08:00:00Z", time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.raw, now)
+		if err != nil {
+			t.Fatalf("parseTimeBound(%q) returned error: %v", tt.raw, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Fatalf("parseTimeBound(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+
+	for _, raw := range []string{"yesterday-ish", "2 fortnights ago", "days ago", "-2d"} {
+		if _, err := parseTimeBound(raw, now); err == nil || !strings.Contains(err.Error(), `"2 days ago"`) {
+			t.Fatalf("expected an error listing the accepted syntax for %q, got %v", raw, err)
+		}
+	}
+	if _, err := parseWindow("2026-09-10", "2026-09-01", now); err == nil {
+		t.Fatal("expected error when until precedes since")
+	}
+}
+
+func TestTimeWindowDescribe(t *testing.T) {
+	now := time.Now()
+	if got := (timeWindow{Since: now.Add(-30 * 24 * time.Hour)}).describe(now); got != "in the last 30 days" {
+		t.Fatalf("unexpected description: %q", got)
+	}
+	if got := (timeWindow{}).describe(now); got != "on record" {
+		t.Fatalf("unexpected description: %q", got)
+	}
+}
+
+func TestResolvedIncidentsPagesAndMergesHistory(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+
+	pages := map[string][]incident{
+		"":  {{ID: "p1", Status: "resolved", UpdatedAt: at(24 * time.Hour)}},
+		"2": {{ID: "p2", Status: "resolved", UpdatedAt: at(10 * 24 * time.Hour)}},
+		"3": {{ID: "p3", Status: "resolved", UpdatedAt: at(40 * 24 * time.Hour)}},
+	}
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/incidents.json", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		json.NewEncoder(w).Encode(incidentResponse{Incidents: pages[page]})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.history = newHistoryStore(t.TempDir())
+	stored := incident{ID: "archived", Status: "resolved", UpdatedAt: at(60 * 24 * time.Hour)}
+	if err := client.history.record(client.siteURL, []incident{stored}, nil, now); err != nil {
+		t.Fatalf("record returned error: %v", err)
+	}
+
+	got, err := client.ResolvedIncidents(context.Background(), timeWindow{Since: now.Add(-30 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("ResolvedIncidents returned error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "p1" || got[1].ID != "p2" {
+		t.Fatalf("unexpected incidents in 30 day window: %+v", got)
+	}
+	if len(requested) != 3 {
+		t.Fatalf("expected paging to stop once past the window, requested %v", requested)
+	}
+
+	got, err = client.ResolvedIncidents(context.Background(), timeWindow{Since: now.Add(-90 * 24 * time.Hour), Until: now.Add(-30 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("ResolvedIncidents returned error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "p3" || got[1].ID != "archived" {
+		t.Fatalf("expected page 3 and stored history, got %+v", got)
+	}
+
+	all, err := client.history.incidents(client.siteURL)
+	if err != nil || len(all) != 4 {
+		t.Fatalf("expected fetched incidents to be recorded once, got %d (%v)", len(all), err)
+	}
+}
+
+func TestHistoryStoreRecordsObservations(t *testing.T) {
+	server := newStatusServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := newTestClient(server)
+	client.history = newHistoryStore(dir)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Components(context.Background()); err != nil {
+			t.Fatalf("Components returned error: %v", err)
+		}
+		if _, err := client.ActiveIncidents(context.Background()); err != nil {
+			t.Fatalf("ActiveIncidents returned error: %v", err)
+		}
+		// A new process starts from the sidecar rather than memory.
+		client.history = newHistoryStore(dir)
+	}
+
+	countRecords := func(store *historyStore, page string) (components, incidents, updates int) {
+		t.Helper()
+		records, err := store.read(page)
+		if err != nil {
+			t.Fatalf("read returned error: %v", err)
+		}
+		for _, rec := range records {
+			switch {
+			case rec.Component != nil:
+				components++
+			case rec.Incident != nil:
+				incidents++
+			case rec.Update != nil:
+				updates++
+			}
+		}
+		return components, incidents, updates
+	}
+	if components, incidents, updates := countRecords(client.history, client.siteURL); components != 3 || incidents != 1 || updates != 1 {
+		t.Fatalf("expected each observation stored once, got %d components, %d incidents and %d updates", components, incidents, updates)
+	}
+	if comps, err := client.history.components(client.siteURL); err != nil || len(comps) != 3 {
+		t.Fatalf("expected the stored components back, got %+v (%v)", comps, err)
+	}
+
+	first := incidentUpdate{ID: "u1", Status: "investigating", CreatedAt: "2026-09-01T10:00:00Z"}
+	second := incidentUpdate{ID: "u2", Status: "resolved", CreatedAt: "2026-09-01T11:00:00Z"}
+	update := incident{ID: "inc", Status: "investigating", UpdatedAt: "2026-09-01T10:00:00Z", IncidentUpdates: []incidentUpdate{first}}
+	resolved := incident{ID: "inc", Status: "resolved", UpdatedAt: "2026-09-01T11:00:00Z", IncidentUpdates: []incidentUpdate{second, first}}
+	store := newHistoryStore(t.TempDir())
+	store.record("page", []incident{update}, nil, time.Now())
+	store.record("page", []incident{resolved}, nil, time.Now())
+	store.record("page", []incident{resolved}, nil, time.Now())
+
+	if _, incidents, updates := countRecords(store, "page"); incidents != 2 || updates != 2 {
+		t.Fatalf("expected one record per revision and per update, got %d incidents and %d updates", incidents, updates)
+	}
+	merged, err := store.incidents("page")
+	if err != nil || len(merged) != 1 {
+		t.Fatalf("expected one merged incident, got %d (%v)", len(merged), err)
+	}
+	if merged[0].Status != "resolved" || len(merged[0].IncidentUpdates) != 2 || merged[0].IncidentUpdates[0].ID != "u2" {
+		t.Fatalf("expected latest revision with all updates, got %+v", merged[0])
+	}
+
+}
+
+func TestClientSkipsHistoryForCachedResponses(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/components.json", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "max-age=60")
+		json.NewEncoder(w).Encode(statusResponse{Components: []component{{ID: "api", Name: "API", Status: "operational"}}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := newTestClient(server)
+	client.cache = newHTTPCache(t.TempDir(), 0)
+	client.history = newHistoryStore(dir)
+
+	if _, err := client.Components(context.Background()); err != nil {
+		t.Fatalf("Components returned error: %v", err)
+	}
+	if err := os.Remove(client.history.path(client.siteURL)); err != nil {
+		t.Fatalf("remove history: %v", err)
+	}
+	if _, err := client.Components(context.Background()); err != nil {
+		t.Fatalf("Components returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the second call to be served from the cache, got %d requests", calls)
+	}
+	if _, err := os.Stat(client.history.path(client.siteURL)); !os.IsNotExist(err) {
+		t.Fatalf("expected a cached response not to be recorded again, got %v", err)
+	}
+}
+
+func TestQueryHistory(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	incidents := []incident{
+		{ID: "a", Name: "Actions outage", Impact: "major", Status: "resolved", CreatedAt: "2026-09-05T10:00:00Z", ResolvedAt: "2026-09-05T12:00:00Z", Components: []component{{Name: "Actions"}}},
+		{ID: "b", Name: "Pages blip", Impact: "minor", Status: "resolved", CreatedAt: "2026-09-20T10:00:00Z", ResolvedAt: "2026-09-20T10:30:00Z", Components: []component{{Name: "Pages"}}},
+		{ID: "c", Name: "Old", Impact: "major", Status: "resolved", CreatedAt: "2026-06-01T10:00:00Z", ResolvedAt: "2026-06-01T11:00:00Z"},
+		{ID: "d", Name: "Spanning", Impact: "critical", Status: "resolved", CreatedAt: "2026-08-31T22:00:00Z", ResolvedAt: "2026-09-01T02:00:00Z"},
+	}
+	window := timeWindow{Since: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)}
+
+	ids := func(tls []incidentTimeline) string {
+		var out []string
+		for _, tl := range tls {
+			out = append(out, tl.Incident.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := ids(queryHistory(incidents, window, componentFilter{}, componentGroups{}, nil, now)); got != "b,a,d" {
+		t.Fatalf("unexpected incidents in window: %s", got)
+	}
+	if got := ids(queryHistory(incidents, window, componentFilter{include: []string{"actions"}}, componentGroups{}, nil, now)); got != "a" {
+		t.Fatalf("unexpected incidents for component filter: %s", got)
+	}
+	if got := ids(queryHistory(incidents, window, componentFilter{}, componentGroups{}, []string{"major", "critical"}, now)); got != "a,d" {
+		t.Fatalf("unexpected incidents for impact filter: %s", got)
+	}
+
+	// Components named only in updates, matched by their own name or their
+	// group's, select an incident just as they do for the status report.
+	known := []component{
+		{ID: "grp", Name: "Developer Tools", Group: true},
+		{ID: "act", Name: "Actions", GroupID: "grp"},
+	}
+	updated := incident{ID: "e", Name: "Runner delays", Impact: "minor", Status: "resolved", CreatedAt: "2026-09-10T10:00:00Z", ResolvedAt: "2026-09-10T11:00:00Z",
+		IncidentUpdates: []incidentUpdate{{Status: "resolved", CreatedAt: "2026-09-10T11:00:00Z", AffectedComponents: []affectedComponent{{Code: "act", Name: "Actions", NewStatus: "operational"}}}}}
+	groups := newComponentGroups(known)
+	for _, include := range []string{"actions", "developer tools"} {
+		filter := componentFilter{include: []string{include}}
+		if got := ids(queryHistory([]incident{updated}, window, filter, groups, nil, now)); got != "e" {
+			t.Fatalf("expected --component %q to select the incident, got %q", include, got)
+		}
+		if selected := filterComponents(known, filter); !newComponentSet(selected).affectedBy(updated, filter) {
+			t.Fatalf("status report filter disagrees for %q", include)
+		}
+	}
+	if got := ids(queryHistory([]incident{updated}, window, componentFilter{exclude: []string{"developer tools"}}, groups, nil, now)); got != "" {
+		t.Fatalf("expected the group exclusion to drop the incident, got %q", got)
+	}
+}
+
+func TestParseHistoryFlags(t *testing.T) {
+	cfg, err := parseHistoryFlags([]string{"--since", "2026-09-01", "--impact", "Major", "--component", "Actions"})
+	if err != nil {
+		t.Fatalf("parseHistoryFlags returned error: %v", err)
+	}
+	if cfg.window.Since.IsZero() || len(cfg.impacts) != 1 || cfg.impacts[0] != "major" || len(cfg.filter.include) != 1 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if _, err := parseHistoryFlags([]string{"--impact", "huge"}); err == nil {
+		t.Fatal("expected error for unknown impact")
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)
+	window := timeWindow{Since: now.Add(-10 * 24 * time.Hour)}
+	actions := []component{{Name: "Actions"}}
+	incidents := []incident{
+		{ID: "a", Name: "Runners slow", Impact: "minor", Status: "resolved", CreatedAt: "2026-10-02T10:00:00Z", ResolvedAt: "2026-10-02T12:00:00Z", Components: actions},
+		{ID: "b", Name: "Runners down", Impact: "major", Status: "resolved", CreatedAt: "2026-10-02T11:00:00Z", ResolvedAt: "2026-10-02T14:00:00Z", Components: []component{{Name: "Actions"}, {Name: "Pages"}}},
+		{ID: "c", Name: "Started before window", Impact: "critical", Status: "resolved", CreatedAt: "2026-09-30T23:00:00Z", ResolvedAt: "2026-10-01T01:00:00Z", Components: actions},
+		{ID: "d", Name: "Notice", Impact: "none", Status: "resolved", CreatedAt: "2026-10-05T10:00:00Z", ResolvedAt: "2026-10-05T20:00:00Z", Components: actions},
+	}
+
+	rep := computeStats(queryHistory(incidents, window, componentFilter{}, componentGroups{}, nil, now), nil, window, componentFilter{}, now)
+	if len(rep.Components) != 2 || rep.Components[0].Name != "Actions" {
+		t.Fatalf("unexpected components: %+v", rep.Components)
+	}
+
+	got := rep.Components[0]
+	if got.Incidents != 4 || got.ByImpact["major"] != 1 || got.ByImpact["critical"] != 1 || got.ByImpact["none"] != 1 {
+		t.Fatalf("unexpected counts: %+v", got)
+	}
+	// 10:00-14:00 after merging a and b, plus the hour of c inside the window.
+	if got.Impacted != 5*time.Hour {
+		t.Fatalf("expected 5h impacted, got %s", got.Impacted)
+	}
+	if want := 100 * (1 - 5.0/240); got.Availability < want-0.0001 || got.Availability > want+0.0001 {
+		t.Fatalf("expected availability %.4f, got %.4f", want, got.Availability)
+	}
+	if got.MTTR != (2*time.Hour+3*time.Hour+2*time.Hour+10*time.Hour)/4 {
+		t.Fatalf("unexpected MTTR: %s", got.MTTR)
+	}
+	if got.Longest.Incident.ID != "d" {
+		t.Fatalf("expected longest incident d, got %q", got.Longest.Incident.ID)
+	}
+	if rep.Total.Incidents != 4 {
+		t.Fatalf("expected total of 4 incidents, got %d", rep.Total.Incidents)
+	}
+
+	filtered := computeStats(queryHistory(incidents, window, componentFilter{include: []string{"pages"}}, componentGroups{}, nil, now), nil, window, componentFilter{include: []string{"pages"}}, now)
+	if len(filtered.Components) != 1 || filtered.Components[0].Name != "Pages" || filtered.Total.Incidents != 1 {
+		t.Fatalf("unexpected filtered stats: %+v", filtered)
+	}
+
+	known := []component{{Name: "Actions"}, {Name: "Pages"}, {Name: "Codespaces"}, {Name: referenceComponent}}
+	quiet := componentFilter{include: []string{"codespaces"}}
+	seeded := computeStats(queryHistory(incidents, window, quiet, componentGroups{}, nil, now), known, window, quiet, now)
+	if len(seeded.Components) != 1 || seeded.Components[0].Name != "Codespaces" || seeded.Components[0].Incidents != 0 || seeded.Components[0].Availability != 100 {
+		t.Fatalf("expected a full-availability row for a component without incidents: %+v", seeded.Components)
+	}
+	if all := computeStats(queryHistory(incidents, window, componentFilter{}, componentGroups{}, nil, now), known, window, componentFilter{}, now); len(all.Components) != 3 || all.Components[2].Name != "Codespaces" {
+		t.Fatalf("expected every known component to get a row: %+v", all.Components)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := renderStatsText(buf, seeded, now, style{}); err != nil {
+		t.Fatalf("renderStatsText returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Codespaces") || !strings.Contains(buf.String(), "100.000%") {
+		t.Fatalf("expected the quiet component in the table:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "recorded history only") {
+		t.Fatalf("expected no refresh warning:\n%s", buf.String())
+	}
+
+	seeded.RefreshErr = errors.New("refresh history: boom")
+	buf.Reset()
+	if err := renderStatsText(buf, seeded, now, style{icons: iconsNone}); err != nil {
+		t.Fatalf("renderStatsText returned error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Showing recorded history only; the status page could not be reached.\n  refresh history: boom\n") {
+		t.Fatalf("expected a refresh warning before the table:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := renderStatsJSON(buf, "page", seeded); err != nil {
+		t.Fatalf("renderStatsJSON returned error: %v", err)
+	}
+	var payload jsonStats
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil || !payload.Stale || payload.RefreshError != "refresh history: boom" {
+		t.Fatalf("expected stale stats JSON, got %+v (%v)", payload, err)
+	}
+}
+
+func TestHistoryCommandsWhenRefreshFails(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	common := []string{"--status-page", server.URL, "--retries", "0"}
+	if code := run(append([]string{"at", "1h ago"}, common...)); code != exitError {
+		t.Fatalf("expected at to fail when history cannot be refreshed, got %d", code)
+	}
+	if code := run(append([]string{"at", "1h ago", "--offline"}, common...)); code != exitOK {
+		t.Fatalf("expected at --offline to use the store, got %d", code)
+	}
+
+	buf := &bytes.Buffer{}
+	renderHistoryText(buf, nil, timeWindow{}, errors.New("refresh history: boom"), time.Now(), style{icons: iconsNone})
+	if !strings.HasPrefix(buf.String(), "Showing recorded history only") {
+		t.Fatalf("expected a refresh warning in history output:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := renderHistoryJSON(buf, "page", nil, timeWindow{}, errors.New("refresh history: boom")); err != nil {
+		t.Fatalf("renderHistoryJSON returned error: %v", err)
+	}
+	var payload jsonHistory
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil || !payload.Stale || payload.RefreshError == "" {
+		t.Fatalf("expected stale history JSON, got %+v (%v)", payload, err)
+	}
+}
+
+func TestFindOverlaps(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	incidents := []incident{
+		{
+			ID: "actions", Name: "Actions degraded", Impact: "major", Status: "resolved",
+			CreatedAt: "2026-10-15T09:55:00Z", Components: []component{{Name: "Actions"}},
+			IncidentUpdates: []incidentUpdate{
+				{Status: "resolved", CreatedAt: "2026-10-15T12:00:00Z", AffectedComponents: []affectedComponent{{Name: "Actions", OldStatus: "partial_outage", NewStatus: "operational"}}},
+				{Status: "identified", CreatedAt: "2026-10-15T11:00:00Z", AffectedComponents: []affectedComponent{{Name: "Actions", OldStatus: "degraded_performance", NewStatus: "partial_outage"}}},
+				{Status: "investigating", CreatedAt: "2026-10-15T10:00:00Z", AffectedComponents: []affectedComponent{{Name: "Actions", OldStatus: "operational", NewStatus: "degraded_performance"}}},
+			},
+		},
+		{
+			ID: "pages", Name: "Pages notice", Impact: "none", Status: "resolved",
+			IncidentUpdates: []incidentUpdate{
+				{Status: "resolved", CreatedAt: "2026-10-15T13:00:00Z"},
+				{Status: "investigating", CreatedAt: "2026-10-15T12:30:00Z"},
+			},
+		},
+	}
+
+	at := func(raw string) timeWindow {
+		w, err := parseRange(raw, now)
+		if err != nil {
+			t.Fatalf("parseRange(%q) returned error: %v", raw, err)
+		}
+		return w
+	}
+
+	got := findOverlaps(incidents, at("2026-10-15T10:30:00Z"), componentFilter{}, componentGroups{}, now)
+	if len(got) != 1 || got[0].Timeline.Incident.ID != "actions" {
+		t.Fatalf("unexpected overlaps: %+v", got)
+	}
+	if got[0].Status != "investigating" || got[0].Components[0].Status != "degraded_performance" || got[0].Health != healthPartialOutage {
+		t.Fatalf("unexpected state at 10:30: %+v", got[0])
+	}
+
+	// Lifetime starts at the first update, not when the incident was created.
+	if got := findOverlaps(incidents, at("2026-10-15T09:58:00Z"), componentFilter{}, componentGroups{}, now); len(got) != 0 {
+		t.Fatalf("expected no overlap before the first update, got %+v", got)
+	}
+
+	got = findOverlaps(incidents, at("2026-10-15T11:30:00Z..2026-10-15T12:45:00Z"), componentFilter{}, componentGroups{}, now)
+	if len(got) != 2 || overlapsHealth(got) != healthPartialOutage {
+		t.Fatalf("unexpected overlaps for range: %+v", got)
+	}
+	if got[0].Components[0].Status != "operational" || got[1].Health != healthOperational {
+		t.Fatalf("unexpected state for range: %+v", got)
+	}
+
+	if got := findOverlaps(incidents, at("2026-10-15T10:30:00Z"), componentFilter{include: []string{"pages"}}, componentGroups{}, now); len(got) != 0 {
+		t.Fatalf("expected component filter to drop incident, got %+v", got)
+	}
+}
+
+func TestParseRelativeTimes(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	if got, err := parseTimeBound("2h ago", now); err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("parseTimeBound(2h ago) = %s, %v", got, err)
+	}
+	if got, err := parseTimeBound("09:30", now); err != nil || !got.Equal(time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local)) {
+		t.Fatalf("parseTimeBound(09:30) = %s, %v", got, err)
+	}
+	if _, err := parseRange("..2026-10-15", now); err == nil {
+		t.Fatal("expected error for open range")
+	}
+	cfg, err := parseAtFlags([]string{"3h", "ago", "--component", "Actions"})
+	if err != nil || !cfg.window.Since.Equal(cfg.window.Until) || len(cfg.filter.include) != 1 {
+		t.Fatalf("unexpected at config: %+v, %v", cfg, err)
+	}
+}
+
+func TestIncidentComponents(t *testing.T) {
+	inc := incident{
+		ID:         "inc",
+		Name:       "Actions degraded",
+		Status:     "investigating",
+		Impact:     "minor",
+		Components: []component{{ID: "act", Name: "Actions"}},
+		IncidentUpdates: []incidentUpdate{
+			{Status: "investigating", CreatedAt: "2026-10-15T10:00:00Z", AffectedComponents: []affectedComponent{
+				{Code: "act", Name: "Actions", NewStatus: "degraded_performance"},
+				{Code: "pkg", Name: "Packages", NewStatus: "degraded_performance"},
+			}},
+		},
+	}
+	if got := strings.Join(incidentComponentNames(inc), ","); got != "Actions,Packages" {
+		t.Fatalf("unexpected components: %s", got)
+	}
+
+	rep := report{
+		Components: []component{
+			{ID: "act", Name: "Actions", Status: "degraded_performance"},
+			{ID: "api", Name: "API Requests", Status: "operational"},
+			{ID: "pkg", Name: "Packages", Status: "degraded_performance"},
+		},
+		Active: []incident{inc},
+	}
+
+	buf := &bytes.Buffer{}
+	renderText(buf, rep, config{showDetails: true, flat: true})
+	out := buf.String()
+	for _, want := range []string{
+		"Actions - Degraded Performance ⚠️  1 open incident",
+		"API Requests - Operational\n",
+		"Affected components: Actions, Packages",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	payload := buildJSONReport(rep, config{flat: true})
+	if payload.Components[0].OpenIncidents != 1 || payload.Components[1].OpenIncidents != 0 {
+		t.Fatalf("unexpected open incident counts: %+v", payload.Components)
+	}
+	if got := strings.Join(payload.ActiveIncidents[0].Components, ","); got != "Actions,Packages" {
+		t.Fatalf("unexpected JSON incident components: %s", got)
+	}
+
+	filtered := filterIncidents(rep.Active, rep.Components[2:], componentFilter{include: []string{"packages"}})
+	if len(filtered) != 1 {
+		t.Fatal("expected incident to match a component named only in its updates")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	rep := report{
+		StatusPage: statusSiteURL,
+		Components: []component{
+			{Name: "Actions", Status: "degraded_performance"},
+			{Name: "API Requests", Status: "operational"},
+		},
+	}
+	cfg := config{flat: true, template: `{{range .components}}{{statusIcon .status}} {{.name}}: {{formatStatus .status}}{{"\n"}}{{end}}{{truncate 5 .health}}`}
+
+	buf := &bytes.Buffer{}
+	if err := renderTemplate(buf, buildJSONReport(rep, cfg), cfg); err != nil {
+		t.Fatalf("renderTemplate returned error: %v", err)
+	}
+	want := "🟡 Actions: Degraded Performance\n🟢 API Requests: Operational\nde..."
+	if buf.String() != want {
+		t.Fatalf("unexpected template output:\n%q\nwant:\n%q", buf.String(), want)
+	}
+
+	for _, tc := range []struct {
+		color bool
+		want  string
+	}{
+		{false, "down"},
+		{true, "\x1b[0;31mdown\x1b[0m"},
+	} {
+		cfg := config{template: `{{autocolor "red" .x}}`, style: style{color: tc.color}}
+		buf.Reset()
+		if err := renderTemplate(buf, map[string]string{"x": "down"}, cfg); err != nil {
+			t.Fatalf("renderTemplate returned error: %v", err)
+		}
+		if buf.String() != tc.want {
+			t.Fatalf("autocolor with color %v = %q, want %q", tc.color, buf.String(), tc.want)
+		}
+	}
+
+	if _, err := parseFlags([]string{"--template", "{{.components"}); err == nil {
+		t.Fatal("expected error for invalid template")
+	}
+
+	path := filepath.Join(t.TempDir(), "status.tmpl")
+	if err := os.WriteFile(path, []byte("{{.health}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseFlags([]string{"--template-file", path})
+	if err != nil || parsed.output != outputTemplate || parsed.template != "{{.health}}" {
+		t.Fatalf("unexpected config from --template-file: %+v, %v", parsed, err)
+	}
+}
+
+func TestRenderJSONWithJQ(t *testing.T) {
+	rep := report{
+		StatusPage: statusSiteURL,
+		Components: []component{
+			{Name: "Actions", Status: "degraded_performance"},
+			{Name: "API Requests", Status: "operational"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	cfg := config{flat: true, jq: `.components[] | select(.status != "operational") | .name`}
+	if err := renderJSON(buf, rep, cfg); err != nil {
+		t.Fatalf("renderJSON returned error: %v", err)
+	}
+	if buf.String() != "Actions\n" {
+		t.Fatalf("unexpected jq output: %q", buf.String())
+	}
+
+	cfg.jq = ".health | error"
+	if err := renderJSON(io.Discard, rep, cfg); err == nil {
+		t.Fatal("expected runtime jq error")
+	}
+
+	parsed, err := parseFlags([]string{"--jq", ".health"})
+	if err != nil || parsed.output != outputJSON {
+		t.Fatalf("expected --jq to imply JSON output, got %+v, %v", parsed, err)
+	}
+	if _, err := parseFlags([]string{"--jq", ".components[] |"}); err == nil || !strings.Contains(err.Error(), "invalid --jq expression") {
+		t.Fatalf("expected invalid expression error, got %v", err)
+	}
+	if code := run([]string{"--jq", "{"}); code != exitUsage {
+		t.Fatalf("expected exit code %d for invalid expression, got %d", exitUsage, code)
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	rep := report{
+		StatusPage: statusSiteURL,
+		FetchedAt:  now,
+		Components: []component{
+			{ID: "act", Name: "Actions", Status: "degraded_performance", UpdatedAt: "2026-10-16T10:00:00Z"},
+			{ID: "api", Name: "API Requests", Status: "operational", UpdatedAt: "2026-10-16T11:00:00Z"},
+		},
+		Active: []incident{{
+			ID: "inc1", Name: "Actions degraded", Impact: "minor", Status: "investigating",
+			CreatedAt: "2026-10-16T11:30:00Z", Components: []component{{ID: "act", Name: "Actions"}},
+		}},
+	}
+
+	buf, warn := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := renderTable(buf, warn, rep, config{flat: true}, false, 80, now); err != nil {
+		t.Fatalf("renderTable returned error: %v", err)
+	}
+	want := "Actions\tDegraded Performance\t1\t2026-10-16T10:00:00Z\n" +
+		"API Requests\tOperational\t0\t2026-10-16T11:00:00Z\n" +
+		"\n" +
+		"inc1\tminor\tInvestigating\t2026-10-16T11:30:00Z\t1800\tActions degraded\n"
+	if buf.String() != want || warn.Len() != 0 {
+		t.Fatalf("unexpected TSV output:\n%q\nwant:\n%q\nwarnings: %q", buf.String(), want, warn.String())
+	}
+
+	stale := rep
+	stale.Stale = true
+	stale.FetchedAt = now.Add(-2 * time.Hour)
+	stale.FetchErr = "connection refused"
+	buf.Reset()
+	if err := renderTable(buf, warn, stale, config{flat: true}, false, 80, now); err != nil {
+		t.Fatalf("renderTable returned error: %v", err)
+	}
+	if buf.String() != want {
+		t.Fatalf("expected stale TSV rows to stay unchanged:\n%q", buf.String())
+	}
+	if !strings.Contains(warn.String(), "saved snapshot") || !strings.Contains(warn.String(), "2h ago") || !strings.Contains(warn.String(), "connection refused") {
+		t.Fatalf("expected a stale warning on stderr, got %q", warn.String())
+	}
+
+	buf.Reset()
+	if err := renderTable(buf, io.Discard, rep, config{flat: true}, true, 120, now); err != nil {
+		t.Fatalf("renderTable returned error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"COMPONENT", "OPEN INCIDENTS", "about 2 hours ago", "30m so far"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in table output:\n%s", want, out)
+		}
+	}
+
+	cfg, err := parseFlags([]string{"--format", "table"})
+	if err != nil || cfg.output != outputTable {
+		t.Fatalf("unexpected config for --format table: %+v, %v", cfg, err)
+	}
+	if _, err := parseFlags([]string{"--format", "table", "--json"}); err == nil {
+		t.Fatal("expected --format table and --json to conflict")
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	rep := report{
+		StatusPage: statusSiteURL,
+		FetchedAt:  now,
+		Components: []component{
+			{ID: "act", Name: "Actions", Status: "degraded_performance"},
+			{ID: "pages", Name: "Pages | Sites", Status: "operational"},
+		},
+		Active: []incident{{
+			ID: "inc1", Name: "Actions <degraded>", Impact: "minor", Status: "investigating",
+			Shortlink:  "https://stspg.io/abc",
+			Components: []component{{ID: "act", Name: "Actions"}},
+			IncidentUpdates: []incidentUpdate{
+				{Status: "identified", Body: "Rolled back <b>v2</b> *again* </details> [x]", CreatedAt: "2026-10-16T11:45:00Z"},
+				{Status: "investigating", Body: "Looking\ninto it.", CreatedAt: "2026-10-16T11:30:00Z"},
+			},
+		}},
+	}
+
+	buf := &bytes.Buffer{}
+	renderMarkdown(buf, rep, config{flat: true})
+	out := buf.String()
+	for _, want := range []string{
+		"## GitHub Service Status",
+		"| Component | Status | Open incidents |",
+		"| Actions | 🟡 Degraded Performance | 1 |",
+		"| Pages \\| Sites | 🟢 Operational | 0 |",
+		"<summary>🟡 Actions &lt;degraded&gt; · Minor impact · Investigating</summary>",
+		"- **Investigating** (Oct 16 11:30 UTC): Looking into it.",
+		"- **Identified** (Oct 16 11:45 UTC): Rolled back &lt;b&gt;v2&lt;/b&gt; \\*again\\* &lt;/details&gt; \\[x\\]",
+		"[More info](https://stspg.io/abc)",
+		"</details>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in markdown output:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "</details>"); n != 1 {
+		t.Fatalf("expected update bodies not to close the details block, found %d closing tags:\n%s", n, out)
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("# Earlier step\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(stepSummaryEnv, path)
+	if err := appendStepSummary(func(w io.Writer) { renderMarkdown(w, rep, config{flat: true}) }); err != nil {
+		t.Fatalf("appendStepSummary returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Earlier step\n## GitHub Service Status") {
+		t.Fatalf("expected the summary to be appended, got:\n%s", data)
+	}
+
+	cfg, err := parseFlags([]string{"--format", "markdown", "--step-summary"})
+	if err != nil || cfg.output != outputMarkdown || !cfg.stepSummary {
+		t.Fatalf("unexpected config for --format markdown --step-summary: %+v, %v", cfg, err)
+	}
+	t.Setenv(stepSummaryEnv, "")
+	if _, err := parseFlags([]string{"--step-summary"}); err == nil {
+		t.Fatal("expected --step-summary to require GITHUB_STEP_SUMMARY")
+	}
+}
+
+func TestRenderMaintenance(t *testing.T) {
+	start := time.Now().Add(2 * time.Hour).UTC()
+	rep := report{
+		Components: []component{{Name: "API", Status: "operational"}},
+		Maintenances: []maintenance{
+			{
+				incident:       incident{ID: "maint-1", Name: "Database upgrade", Status: "scheduled", Impact: "maintenance"},
+				ScheduledFor:   start.Format(time.RFC3339),
+				ScheduledUntil: start.Add(time.Hour).Format(time.RFC3339),
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	renderText(buf, rep, config{showMaintenance: true})
+	out := buf.String()
+	if !strings.Contains(out, "Scheduled maintenance:") || !strings.Contains(out, "Database upgrade") {
+		t.Fatalf("missing maintenance section:\n%s", out)
+	}
+	if !strings.Contains(out, "Window: "+formatTimestamp(start.Format(time.RFC3339))) {
+		t.Fatalf("missing maintenance window:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := renderJSON(buf, rep, config{}); err != nil {
+		t.Fatalf("renderJSON returned error: %v", err)
+	}
+	var payload jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("cannot unmarshal JSON: %v\n%s", err, buf.String())
+	}
+	if len(payload.ScheduledMaintenances) != 1 || payload.ScheduledMaintenances[0].ScheduledFor == "" {
+		t.Fatalf("unexpected maintenances: %#v", payload.ScheduledMaintenances)
+	}
+}
+
+func newTestClient(server *httptest.Server) *statusClient {
+	client := newStatusClient(server.URL+"/", 5*time.Second)
+	client.http = server.Client()
+	client.retry.baseDelay = time.Millisecond
+	client.retry.maxDelay = 10 * time.Millisecond
+	return client
+}
+
+func newStatusServer() *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v2/components.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := statusResponse{
+			Components: []component{
+				{Name: "API Requests", Status: "operational", Group: false},
+				{Name: "Codespaces", Status: "major_outage", Group: false},
+				{Name: referenceComponent, Status: "operational", Group: false},
+				{Name: "Group Container", Status: "operational", Group: true},
+			},
+		}
+		json.NewEncoder(w).Encode(payload)
+	})
+
+	now := time.Now().UTC()
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-10 * 24 * time.Hour)
+
+	mux.HandleFunc("/api/v2/incidents/unresolved.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := incidentResponse{
+			Incidents: []incident{
+				{
+					ID:        "active-1",
+					Name:      "Active Incident",
+					Status:    "investigating",
+					Impact:    "major",
+					UpdatedAt: recent.Format(time.RFC3339),
+					IncidentUpdates: []incidentUpdate{
+						{Status: "investigating", Body: "Investigating", CreatedAt: recent.Format(time.RFC3339)},
+					},
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(payload)
+	})
+
+	mux.HandleFunc("/api/v2/incidents.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := incidentResponse{
+			Incidents: []incident{
+				{
+					ID:        "resolved-new",
+					Name:      "Recent Incident",
+					Status:    "resolved",
+					Impact:    "major",
+					UpdatedAt: recent.Format(time.RFC3339),
+					IncidentUpdates: []incidentUpdate{
+						{Status: "resolved", Body: "Fixed", CreatedAt: recent.Format(time.RFC3339)},
+					},
+				},
+				{
+					ID:        "resolved-old",
+					Name:      "Old Incident",
+					Status:    "resolved",
+					Impact:    "major",
+					UpdatedAt: old.Format(time.RFC3339),
+					IncidentUpdates: []incidentUpdate{
+						{Status: "resolved", Body: "Old fix", CreatedAt: old.Format(time.RFC3339)},
+					},
+				},
+				{
+					ID:        "monitoring",
+					Name:      "Monitoring Incident",
+					Status:    "monitoring",
+					Impact:    "minor",
+					UpdatedAt: recent.Format(time.RFC3339),
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(payload)
+	})
+
+	upcoming := now.Add(48 * time.Hour)
+
+	mux.HandleFunc("/api/v2/scheduled-maintenances/active.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := maintenanceResponse{
+			ScheduledMaintenances: []maintenance{
+				{
+					incident:       incident{ID: "maint-active", Name: "Database upgrade", Status: "in_progress", Impact: "maintenance"},
+					ScheduledFor:   now.Add(-time.Hour).Format(time.RFC3339),
+					ScheduledUntil: now.Add(time.Hour).Format(time.RFC3339),
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(payload)
+	})
+
+	mux.HandleFunc("/api/v2/scheduled-maintenances/upcoming.json", func(w http.ResponseWriter, r *http.Request) {
+		payload := maintenanceResponse{
+			ScheduledMaintenances: []maintenance{
+				{
+					incident:       incident{ID: "maint-upcoming", Name: "Network maintenance", Status: "scheduled", Impact: "maintenance"},
+					ScheduledFor:   upcoming.Format(time.RFC3339),
+					ScheduledUntil: upcoming.Add(2 * time.Hour).Format(time.RFC3339),
 				},
 			},
 		}
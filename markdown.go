@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// stepSummaryEnv names the file GitHub Actions renders as the job summary.
+const stepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// renderMarkdown prints the report for pasting into issues, pull requests
+// and chat: a components table followed by a collapsible section per
+// incident. Times are UTC since readers may be anywhere, and colors are
+// dropped; --icons still applies.
+func renderMarkdown(w io.Writer, r report, cfg config) {
+	st := style{icons: cfg.style.icons}
+
+	fmt.Fprintf(w, "## %s Service Status\n\n", markdownEscape(pageTitle(r)))
+	fmt.Fprintf(w, "_As of %s_\n\n", reportTime(r).UTC().Format("Jan 02 15:04 MST"))
+	if r.Stale {
+		fmt.Fprintf(w, "> %sShowing saved snapshot from %s ago; live status was not fetched.\n\n", st.warning(), formatAge(time.Since(reportTime(r))))
+	}
+	if r.Status.Description != "" {
+		fmt.Fprintf(w, "**%s%s**\n\n", st.icon(r.Status.Indicator), markdownEscape(r.Status.Description))
+	}
+
+	open := countOpenIncidents(r.Active)
+	fmt.Fprintln(w, "| Component | Status | Open incidents |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	row := func(indent string, comp component) {
+		fmt.Fprintf(w, "| %s%s | %s%s | %d |\n", indent, markdownCell(comp.Name), st.icon(comp.Status), formatStatus(comp.Status), open.of(comp))
+	}
+	if cfg.flat || len(r.Tree) == 0 {
+		for _, comp := range r.Components {
+			row("", comp)
+		}
+	} else {
+		for _, node := range r.Tree {
+			row("", node.component)
+			for _, child := range node.Children {
+				row("↳ ", child)
+			}
+		}
+	}
+
+	fmt.Fprintln(w)
+	printMarkdownIncidents(w, st, "Active incidents", r.Active, "No active incidents at this time.")
+	if cfg.showResolved {
+		printMarkdownIncidents(w, st, "Recently resolved incidents", r.Resolved, resolvedEmptyMessage(r))
+	}
+
+	if cfg.showMaintenance {
+		fmt.Fprint(w, "### Scheduled maintenance\n\n")
+		if len(r.Maintenances) == 0 {
+			fmt.Fprintf(w, "%s\n\n", maintenanceEmptyMessage(r))
+		}
+		for _, m := range r.Maintenances {
+			fmt.Fprintf(w, "- %s%s - %s", st.icon(m.Status), markdownLink(m.Name, m.Shortlink), formatStatus(m.Status))
+			if window := formatMarkdownWindow(m.ScheduledFor, m.ScheduledUntil); window != "" {
+				fmt.Fprintf(w, " (%s)", window)
+			}
+			fmt.Fprintln(w)
+		}
+		if len(r.Maintenances) > 0 {
+			fmt.Fprintln(w)
+		}
+	}
+
+	fmt.Fprintf(w, "[Full incident history](%s)\n", statusPageURL(r))
+}
+
+// printMarkdownIncidents prints a <details> block per incident whose summary
+// line carries the name, impact and status, with every update inside. All
+// text from the status page is escaped so it cannot close the block or be
+// read as formatting.
+func printMarkdownIncidents(w io.Writer, st style, title string, incidents []incident, emptyMessage string) {
+	fmt.Fprintf(w, "### %s\n\n", title)
+	if len(incidents) == 0 {
+		fmt.Fprintf(w, "%s\n\n", emptyMessage)
+		return
+	}
+
+	for _, inc := range incidents {
+		summary := st.icon(inc.Status) + html.EscapeString(inc.Name)
+		if impact := formatStatus(inc.Impact); impact != "" && !strings.EqualFold(impact, "None") {
+			summary += " · " + html.EscapeString(impact) + " impact"
+		}
+		summary += " · " + html.EscapeString(formatStatus(inc.Status))
+
+		fmt.Fprintf(w, "<details>\n<summary>%s</summary>\n\n", summary)
+		if names := incidentComponentNames(inc); len(names) > 0 {
+			fmt.Fprintf(w, "**Affected components:** %s\n\n", markdownEscape(strings.Join(names, ", ")))
+		}
+		for _, update := range inc.IncidentUpdates {
+			fmt.Fprintf(w, "- **%s** (%s): %s\n", markdownEscape(formatStatus(update.Status)), markdownTime(update.CreatedAt), markdownEscape(summarizeBody(update.Body)))
+		}
+		if len(inc.IncidentUpdates) > 0 {
+			fmt.Fprintln(w)
+		}
+		if inc.Shortlink != "" {
+			fmt.Fprintf(w, "[More info](%s)\n\n", inc.Shortlink)
+		}
+		fmt.Fprint(w, "</details>\n\n")
+	}
+}
+
+func renderProvidersMarkdown(w io.Writer, providers []providerReport, cfg config) {
+	for i, p := range providers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if p.Err != nil {
+			fmt.Fprintf(w, "## %s Service Status\n\n> Unavailable: %s\n", markdownEscape(pageTitle(p.report)), markdownEscape(p.Err.Error()))
+			continue
+		}
+		renderMarkdown(w, p.report, cfg)
+	}
+}
+
+// appendStepSummary appends the Markdown produced by render to the GitHub
+// Actions job summary.
+func appendStepSummary(render func(io.Writer)) error {
+	path := os.Getenv(stepSummaryEnv)
+	if path == "" {
+		return fmt.Errorf("--step-summary requires $%s, which GitHub Actions sets", stepSummaryEnv)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("write step summary: %w", err)
+	}
+	render(f)
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write step summary: %w", err)
+	}
+	return nil
+}
+
+func markdownTime(raw string) string {
+	if t, ok := parseTime(raw); ok {
+		return t.UTC().Format("Jan 02 15:04 MST")
+	}
+	return raw
+}
+
+func formatMarkdownWindow(start, end string) string {
+	switch {
+	case start == "" && end == "":
+		return ""
+	case end == "":
+		return "from " + markdownTime(start)
+	case start == "":
+		return "until " + markdownTime(end)
+	default:
+		return markdownTime(start) + " - " + markdownTime(end)
+	}
+}
+
+func markdownLink(text, link string) string {
+	if link == "" {
+		return markdownEscape(text)
+	}
+	return "[" + markdownEscape(text) + "](" + link + ")"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;",
+)
+
+// markdownEscape keeps names from the status page from being read as
+// formatting.
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCell escapes s for a table cell, where a pipe ends the cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownEscape(s), "|", `\|`)
+}
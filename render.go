@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,11 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/cli/go-gh/v2/pkg/jq"
+	"github.com/cli/go-gh/v2/pkg/template"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/itchyny/gojq"
 )
 
 const maxIncidentUpdates = 3
@@ -15,7 +21,15 @@ const maxIncidentUpdates = 3
 func renderReport(r report, cfg config) error {
 	switch cfg.output {
 	case outputJSON:
-		return renderJSON(os.Stdout, r)
+		return renderJSON(os.Stdout, r, cfg)
+	case outputTemplate:
+		return renderTemplate(os.Stdout, buildJSONReport(r, cfg), cfg)
+	case outputTable:
+		t := term.FromEnv()
+		return renderTable(os.Stdout, os.Stderr, r, cfg, t.IsTerminalOutput(), terminalWidth(t), time.Now())
+	case outputMarkdown:
+		renderMarkdown(os.Stdout, r, cfg)
+		return nil
 	default:
 		renderText(os.Stdout, r, cfg)
 		return nil
@@ -23,26 +37,87 @@ func renderReport(r report, cfg config) error {
 }
 
 func renderText(w io.Writer, r report, cfg config) {
-	fmt.Fprintf(w, "GitHub Service Status - %s (local time)\n\n", time.Now().Local().Format("Jan 02 15:04"))
+	st := cfg.style
+	fmt.Fprintf(w, "%s Service Status - %s (local time)\n\n", pageTitle(r), reportTime(r).Local().Format("Jan 02 15:04"))
+
+	if r.Stale {
+		fmt.Fprintf(w, "%sShowing saved snapshot from %s ago; live status was not fetched.\n", st.warning(), formatAge(time.Since(reportTime(r))))
+		if r.FetchErr != "" {
+			fmt.Fprintf(w, "  %s\n", r.FetchErr)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if r.Status.Description != "" {
+		fmt.Fprintf(w, "%s%s\n\n", st.icon(r.Status.Indicator), st.paint(r.Status.Indicator, r.Status.Description))
+	}
+
+	open := countOpenIncidents(r.Active)
+	if cfg.flat || len(r.Tree) == 0 {
+		for _, comp := range r.Components {
+			printComponentLine(w, st, "", comp, open.of(comp))
+		}
+	} else {
+		for _, node := range r.Tree {
+			printComponentLine(w, st, "", node.component, open.of(node.component))
+			for _, child := range node.Children {
+				printComponentLine(w, st, "  ", child, open.of(child))
+			}
+		}
+	}
 
-	for _, comp := range r.Components {
-		fmt.Fprintf(w, "%s %s - %s\n", statusIcon(comp.Status), comp.Name, formatStatus(comp.Status))
+	if cfg.check {
+		fmt.Fprintf(w, "\nOverall health: %s\n", st.status(reportHealth(r).String()))
 	}
 
 	if cfg.showDetails {
 		fmt.Fprintln(w)
-		printIncidentSection(w, "Active incidents", r.Active, "No active incidents at this time.")
+		printIncidentSection(w, st, "Active incidents", r.Active, "No active incidents at this time.")
 	}
 
 	if cfg.showResolved {
 		fmt.Fprintln(w)
-		printIncidentSection(w, "Recently resolved incidents", r.Resolved, "No recently resolved incidents in the last 7 days.")
+		printIncidentSection(w, st, "Recently resolved incidents", r.Resolved, resolvedEmptyMessage(r))
+	}
+
+	if cfg.showMaintenance {
+		fmt.Fprintln(w)
+		printMaintenanceSection(w, st, r.Maintenances, maintenanceEmptyMessage(r))
 	}
 
-	fmt.Fprintf(w, "\nSee full incident history: %s\n", statusSiteURL)
+	fmt.Fprintf(w, "\nSee full incident history: %s\n", statusPageURL(r))
 }
 
-func printIncidentSection(w io.Writer, title string, incidents []incident, emptyMessage string) {
+// resolvedEmptyMessage explains an empty resolved section, which for a
+// snapshot saved without one says nothing about the incidents themselves.
+func resolvedEmptyMessage(r report) string {
+	if r.missing(sectionResolved) {
+		return "Resolved incidents were not fetched when this snapshot was saved."
+	}
+	return "No resolved incidents " + r.Window.describe(reportTime(r)) + "."
+}
+
+func maintenanceEmptyMessage(r report) string {
+	if r.missing(sectionMaintenance) {
+		return "Scheduled maintenance was not fetched when this snapshot was saved."
+	}
+	return "No scheduled maintenance at this time."
+}
+
+// printComponentLine prints one component, flagging any open incidents that
+// affect it.
+func printComponentLine(w io.Writer, st style, indent string, comp component, openIncidents int) {
+	marker := ""
+	switch {
+	case openIncidents == 1:
+		marker = " " + st.warning() + "1 open incident"
+	case openIncidents > 1:
+		marker = fmt.Sprintf(" %s%d open incidents", st.warning(), openIncidents)
+	}
+	fmt.Fprintf(w, "%s%s%s - %s%s\n", indent, st.icon(comp.Status), comp.Name, st.status(comp.Status), marker)
+}
+
+func printIncidentSection(w io.Writer, st style, title string, incidents []incident, emptyMessage string) {
 	fmt.Fprintln(w, title+":")
 	if len(incidents) == 0 {
 		fmt.Fprintf(w, "  %s\n", emptyMessage)
@@ -50,11 +125,17 @@ func printIncidentSection(w io.Writer, title string, incidents []incident, empty
 	}
 
 	for _, inc := range incidents {
-		fmt.Fprintf(w, "%s %s\n", statusIcon(inc.Status), inc.Name)
+		fmt.Fprintf(w, "%s%s\n", st.icon(inc.Status), inc.Name)
+		if inc.ID != "" {
+			fmt.Fprintf(w, "  ID: %s\n", inc.ID)
+		}
 		if impact := formatStatus(inc.Impact); impact != "" && !strings.EqualFold(impact, "None") {
-			fmt.Fprintf(w, "  Impact: %s\n", impact)
+			fmt.Fprintf(w, "  Impact: %s\n", st.paint(inc.Impact, impact))
+		}
+		fmt.Fprintf(w, "  Status: %s\n", st.status(inc.Status))
+		if names := incidentComponentNames(inc); len(names) > 0 {
+			fmt.Fprintf(w, "  Affected components: %s\n", strings.Join(names, ", "))
 		}
-		fmt.Fprintf(w, "  Status: %s\n", formatStatus(inc.Status))
 		if inc.Shortlink != "" {
 			fmt.Fprintf(w, "  More info: %s\n", inc.Shortlink)
 		}
@@ -62,7 +143,7 @@ func printIncidentSection(w io.Writer, title string, incidents []incident, empty
 		for _, update := range summarizeUpdates(inc.IncidentUpdates) {
 			fmt.Fprintf(w, "  - [%s] %s: %s\n",
 				formatTimestamp(update.CreatedAt),
-				formatStatus(update.Status),
+				st.status(update.Status),
 				summarizeBody(update.Body),
 			)
 		}
@@ -71,6 +152,42 @@ func printIncidentSection(w io.Writer, title string, incidents []incident, empty
 	}
 }
 
+func printMaintenanceSection(w io.Writer, st style, maintenances []maintenance, emptyMessage string) {
+	fmt.Fprintln(w, "Scheduled maintenance:")
+	if len(maintenances) == 0 {
+		fmt.Fprintf(w, "  %s\n", emptyMessage)
+		return
+	}
+
+	for _, m := range maintenances {
+		fmt.Fprintf(w, "%s%s\n", st.icon(m.Status), m.Name)
+		fmt.Fprintf(w, "  Status: %s\n", st.status(m.Status))
+		if window := formatWindow(m.ScheduledFor, m.ScheduledUntil); window != "" {
+			fmt.Fprintf(w, "  Window: %s\n", window)
+		}
+		if m.Shortlink != "" {
+			fmt.Fprintf(w, "  More info: %s\n", m.Shortlink)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// reportTime is when r was fetched, or now for reports built without a
+// timestamp.
+func reportTime(r report) time.Time {
+	if r.FetchedAt.IsZero() {
+		return time.Now()
+	}
+	return r.FetchedAt
+}
+
+func statusPageURL(r report) string {
+	if r.StatusPage == "" {
+		return statusSiteURL
+	}
+	return r.StatusPage
+}
+
 func summarizeUpdates(updates []incidentUpdate) []incidentUpdate {
 	if len(updates) <= maxIncidentUpdates {
 		return updates
@@ -78,20 +195,57 @@ func summarizeUpdates(updates []incidentUpdate) []incidentUpdate {
 	return updates[:maxIncidentUpdates]
 }
 
-func renderJSON(w io.Writer, r report) error {
+func renderJSON(w io.Writer, r report, cfg config) error {
+	return writeJSON(w, buildJSONReport(r, cfg), cfg)
+}
+
+func buildJSONReport(r report, cfg config) jsonReport {
 	payload := jsonReport{
 		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
-		StatusPage:  statusSiteURL,
+		StatusPage:  statusPageURL(r),
+		Stale:       r.Stale,
+		Missing:     r.Missing,
+		Health:      reportHealth(r).String(),
 		Components:  make([]jsonComponent, 0, len(r.Components)),
 	}
 
-	for _, comp := range r.Components {
-		payload.Components = append(payload.Components, jsonComponent{
-			Name:       comp.Name,
-			Status:     strings.ToLower(strings.TrimSpace(comp.Status)),
-			StatusText: formatStatus(comp.Status),
-			Icon:       statusIcon(comp.Status),
-		})
+	if !r.FetchedAt.IsZero() {
+		payload.FetchedAt = r.FetchedAt.UTC().Format(time.RFC3339)
+	}
+
+	if !r.Window.Since.IsZero() {
+		payload.ResolvedSince = r.Window.Since.UTC().Format(time.RFC3339)
+	}
+	if !r.Window.Until.IsZero() {
+		payload.ResolvedUntil = r.Window.Until.UTC().Format(time.RFC3339)
+	}
+
+	if r.Status.Indicator != "" || r.Status.Description != "" {
+		payload.Status = &jsonPageStatus{
+			Indicator:   strings.ToLower(strings.TrimSpace(r.Status.Indicator)),
+			Description: r.Status.Description,
+			Icon:        statusIcon(r.Status.Indicator),
+		}
+	}
+
+	open := countOpenIncidents(r.Active)
+	withOpen := func(comp component) jsonComponent {
+		entry := buildJSONComponent(comp)
+		entry.OpenIncidents = open.of(comp)
+		return entry
+	}
+	if cfg.flat || len(r.Tree) == 0 {
+		for _, comp := range r.Components {
+			payload.Components = append(payload.Components, withOpen(comp))
+		}
+	} else {
+		for _, node := range r.Tree {
+			entry := withOpen(node.component)
+			for _, child := range node.Children {
+				entry.Children = append(entry.Children, withOpen(child))
+			}
+			payload.Components = append(payload.Components, entry)
+		}
 	}
 
 	if len(r.Active) > 0 {
@@ -108,36 +262,204 @@ func renderJSON(w io.Writer, r report) error {
 		}
 	}
 
+	if len(r.Maintenances) > 0 {
+		payload.ScheduledMaintenances = make([]jsonMaintenance, 0, len(r.Maintenances))
+		for _, m := range r.Maintenances {
+			payload.ScheduledMaintenances = append(payload.ScheduledMaintenances, jsonMaintenance{
+				jsonIncident:   buildJSONIncident(m.incident),
+				ScheduledFor:   m.ScheduledFor,
+				ScheduledUntil: m.ScheduledUntil,
+			})
+		}
+	}
+
+	return payload
+}
+
+// writeJSON prints payload as JSON, filtered through cfg.jq when set.
+func writeJSON(w io.Writer, payload interface{}, cfg config) error {
+	if cfg.jq == "" {
+		return encodeJSON(w, payload)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return jq.Evaluate(bytes.NewReader(data), w, cfg.jq)
+}
+
+// validateJQ reports a --jq expression that cannot be parsed or compiled.
+func validateJQ(expr string) error {
+	query, err := gojq.Parse(expr)
+	if err == nil {
+		_, err = gojq.Compile(query)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid --jq expression: %w", err)
+	}
+	return nil
+}
+
+func encodeJSON(w io.Writer, payload interface{}) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(payload)
 }
 
+// newTemplate parses a --template. The template sees the same data as
+// --json and gets gh's template helpers plus statusIcon and formatStatus.
+// color turns on the color and autocolor helpers, as resolved from --color.
+func newTemplate(w io.Writer, text string, color bool) (*template.Template, error) {
+	tmpl := template.New(w, terminalWidth(term.FromEnv()), color).Funcs(map[string]interface{}{
+		"statusIcon":   statusIcon,
+		"formatStatus": formatStatus,
+	})
+	if err := tmpl.Parse(text); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes cfg.template against the JSON form of payload.
+func renderTemplate(w io.Writer, payload interface{}, cfg config) error {
+	tmpl, err := newTemplate(w, cfg.template, cfg.style.color)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return tmpl.Flush()
+}
+
+func renderProviders(providers []providerReport, cfg config) error {
+	switch cfg.output {
+	case outputJSON:
+		return renderProvidersJSON(os.Stdout, providers, cfg)
+	case outputTemplate:
+		return renderTemplate(os.Stdout, buildJSONProviders(providers, cfg), cfg)
+	case outputTable:
+		t := term.FromEnv()
+		return renderProvidersTable(os.Stdout, os.Stderr, providers, cfg, t.IsTerminalOutput(), terminalWidth(t), time.Now())
+	case outputMarkdown:
+		renderProvidersMarkdown(os.Stdout, providers, cfg)
+		return nil
+	default:
+		renderProvidersText(os.Stdout, providers, cfg)
+		return nil
+	}
+}
+
+func renderProvidersText(w io.Writer, providers []providerReport, cfg config) {
+	for i, p := range providers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if p.Err != nil {
+			fmt.Fprintf(w, "%s Service Status - unavailable\n\n", pageTitle(p.report))
+			fmt.Fprintf(w, "  %v\n", p.Err)
+			continue
+		}
+		renderText(w, p.report, cfg)
+	}
+}
+
+func renderProvidersJSON(w io.Writer, providers []providerReport, cfg config) error {
+	return writeJSON(w, buildJSONProviders(providers, cfg), cfg)
+}
+
+func buildJSONProviders(providers []providerReport, cfg config) jsonProviders {
+	payload := jsonProviders{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Providers:   make([]jsonProvider, 0, len(providers)),
+	}
+
+	for _, p := range providers {
+		entry := jsonProvider{Name: pageTitle(p.report)}
+		if p.Err != nil {
+			entry.jsonReport = jsonReport{StatusPage: statusPageURL(p.report), Components: []jsonComponent{}}
+			entry.Error = p.Err.Error()
+		} else {
+			entry.jsonReport = buildJSONReport(p.report, cfg)
+		}
+		entry.GeneratedAt = ""
+		payload.Providers = append(payload.Providers, entry)
+	}
+
+	return payload
+}
+
+type jsonProviders struct {
+	GeneratedAt string         `json:"generated_at"`
+	Providers   []jsonProvider `json:"providers"`
+}
+
+type jsonProvider struct {
+	Name string `json:"name"`
+	jsonReport
+	Error string `json:"error,omitempty"`
+}
+
 type jsonReport struct {
-	GeneratedAt       string          `json:"generated_at"`
-	StatusPage        string          `json:"status_page"`
-	Components        []jsonComponent `json:"components"`
-	ActiveIncidents   []jsonIncident  `json:"active_incidents,omitempty"`
-	ResolvedIncidents []jsonIncident  `json:"resolved_incidents,omitempty"`
+	GeneratedAt           string            `json:"generated_at,omitempty"`
+	StatusPage            string            `json:"status_page"`
+	FetchedAt             string            `json:"fetched_at,omitempty"`
+	Stale                 bool              `json:"stale"`
+	Missing               []string          `json:"missing_sections,omitempty"`
+	Health                string            `json:"health"`
+	Status                *jsonPageStatus   `json:"status,omitempty"`
+	Components            []jsonComponent   `json:"components"`
+	ActiveIncidents       []jsonIncident    `json:"active_incidents,omitempty"`
+	ResolvedIncidents     []jsonIncident    `json:"resolved_incidents,omitempty"`
+	ResolvedSince         string            `json:"resolved_since,omitempty"`
+	ResolvedUntil         string            `json:"resolved_until,omitempty"`
+	ScheduledMaintenances []jsonMaintenance `json:"scheduled_maintenances,omitempty"`
+}
+
+type jsonPageStatus struct {
+	Indicator   string `json:"indicator"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
 }
 
 type jsonComponent struct {
-	Name       string `json:"name"`
-	Status     string `json:"status"`
-	StatusText string `json:"status_text"`
-	Icon       string `json:"icon"`
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	StatusText  string `json:"status_text"`
+	Icon        string `json:"icon"`
+	Description string `json:"description,omitempty"`
+	Position    int    `json:"position,omitempty"`
+	Group       bool   `json:"group,omitempty"`
+	GroupID     string `json:"group_id,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	// OpenIncidents counts the unresolved incidents affecting the component.
+	OpenIncidents int             `json:"open_incidents"`
+	Children      []jsonComponent `json:"children,omitempty"`
 }
 
 type jsonIncident struct {
+	ID         string               `json:"id,omitempty"`
 	Name       string               `json:"name"`
 	Impact     string               `json:"impact"`
 	Status     string               `json:"status"`
 	StatusText string               `json:"status_text"`
 	Shortlink  string               `json:"shortlink,omitempty"`
 	UpdatedAt  string               `json:"updated_at,omitempty"`
+	Components []string             `json:"components,omitempty"`
 	Updates    []jsonIncidentUpdate `json:"updates,omitempty"`
 }
 
+type jsonMaintenance struct {
+	jsonIncident
+	ScheduledFor   string `json:"scheduled_for,omitempty"`
+	ScheduledUntil string `json:"scheduled_until,omitempty"`
+}
+
 type jsonIncidentUpdate struct {
 	Status     string `json:"status"`
 	StatusText string `json:"status_text"`
@@ -145,14 +467,31 @@ type jsonIncidentUpdate struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+func buildJSONComponent(comp component) jsonComponent {
+	return jsonComponent{
+		ID:          comp.ID,
+		Name:        comp.Name,
+		Status:      strings.ToLower(strings.TrimSpace(comp.Status)),
+		StatusText:  formatStatus(comp.Status),
+		Icon:        statusIcon(comp.Status),
+		Description: comp.Description,
+		Position:    comp.Position,
+		Group:       comp.Group,
+		GroupID:     comp.GroupID,
+		UpdatedAt:   comp.UpdatedAt,
+	}
+}
+
 func buildJSONIncident(inc incident) jsonIncident {
 	result := jsonIncident{
+		ID:         inc.ID,
 		Name:       inc.Name,
 		Impact:     strings.ToLower(strings.TrimSpace(inc.Impact)),
 		Status:     strings.ToLower(strings.TrimSpace(inc.Status)),
 		StatusText: formatStatus(inc.Status),
 		Shortlink:  inc.Shortlink,
 		UpdatedAt:  inc.UpdatedAt,
+		Components: incidentComponentNames(inc),
 	}
 
 	if result.UpdatedAt == "" {
@@ -173,19 +512,152 @@ func buildJSONIncident(inc incident) jsonIncident {
 	return result
 }
 
-func statusIcon(status string) string {
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+
+	iconsEmoji = "emoji"
+	iconsASCII = "ascii"
+	iconsNone  = "none"
+)
+
+// severity is the coarse class of a component, incident or update status
+// that decides its icon and color.
+type severity int
+
+const (
+	severityUnknown severity = iota
+	severityOK
+	severityWarning
+	severityDown
+	severityScheduled
+)
+
+func statusSeverity(status string) severity {
 	switch strings.ToLower(strings.TrimSpace(status)) {
 	case "":
+		return severityUnknown
+	case "operational", "resolved", "completed", "none":
+		return severityOK
+	case "major_outage", "critical", "outage", "major":
+		return severityDown
+	case "scheduled":
+		return severityScheduled
+	default:
+		return severityWarning
+	}
+}
+
+// statusIcon is the emoji for status. Text output goes through style so
+// --icons and --color apply; JSON and templates always carry the emoji.
+func statusIcon(status string) string {
+	switch statusSeverity(status) {
+	case severityUnknown:
 		return "⚪️"
-	case "operational", "resolved", "completed":
+	case severityOK:
 		return "🟢"
-	case "major_outage", "critical", "outage":
+	case severityDown:
 		return "🔴"
+	case severityScheduled:
+		return "🔵"
 	default:
 		return "🟡"
 	}
 }
 
+// style decides how text output marks statuses: which icons to print and
+// whether to color them. The zero value prints emoji without color.
+type style struct {
+	color bool
+	icons string
+}
+
+// newStyle resolves --color and --icons. In auto mode color follows the
+// terminal, NO_COLOR, CLICOLOR and CLICOLOR_FORCE as gh does.
+func newStyle(colorMode, icons string, t term.Term) (style, error) {
+	st := style{icons: icons}
+	switch colorMode {
+	case colorAuto:
+		st.color = t.IsColorEnabled()
+	case colorAlways:
+		st.color = true
+	case colorNever:
+	default:
+		return st, fmt.Errorf("color must be %q, %q or %q", colorAuto, colorAlways, colorNever)
+	}
+	switch icons {
+	case iconsEmoji, iconsASCII, iconsNone:
+	default:
+		return st, fmt.Errorf("icons must be %q, %q or %q", iconsEmoji, iconsASCII, iconsNone)
+	}
+	return st, nil
+}
+
+// icon returns the marker for status followed by a space, or nothing with
+// --icons none.
+func (s style) icon(status string) string {
+	switch s.icons {
+	case iconsNone:
+		return ""
+	case iconsASCII:
+		var marker string
+		switch statusSeverity(status) {
+		case severityUnknown:
+			marker = "[?]"
+		case severityOK:
+			marker = "[OK]"
+		case severityDown:
+			marker = "[DOWN]"
+		case severityScheduled:
+			marker = "[MAINT]"
+		default:
+			marker = "[DEGRADED]"
+		}
+		return s.paint(status, marker) + " "
+	default:
+		return statusIcon(status) + " "
+	}
+}
+
+// status is formatStatus(status), colored by its severity.
+func (s style) status(status string) string {
+	return s.paint(status, formatStatus(status))
+}
+
+// paint colors text with the color of status's severity.
+func (s style) paint(status, text string) string {
+	if !s.color || text == "" {
+		return text
+	}
+	var code string
+	switch statusSeverity(status) {
+	case severityOK:
+		code = "32"
+	case severityWarning:
+		code = "33"
+	case severityDown:
+		code = "31"
+	case severityScheduled:
+		code = "34"
+	default:
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// warning prefixes notices such as stale snapshots and open incidents.
+func (s style) warning() string {
+	switch s.icons {
+	case iconsNone:
+		return ""
+	case iconsASCII:
+		return s.paint("degraded", "[!]") + " "
+	default:
+		return "⚠️  "
+	}
+}
+
 func formatStatus(status string) string {
 	if status == "" {
 		return ""
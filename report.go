@@ -3,53 +3,357 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"path"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type report struct {
-	Components []component
-	Active     []incident
-	Resolved   []incident
+	StatusPage   string
+	FetchedAt    time.Time
+	Page         page
+	Status       pageStatus
+	Components   []component
+	Tree         []componentNode
+	Active       []incident
+	Resolved     []incident
+	Maintenances []maintenance
+	// Window is the range Resolved was drawn from.
+	Window timeWindow
+
+	// Stale marks a report loaded from a saved snapshot rather than fetched
+	// live; FetchErr explains why the live fetch was skipped, if it failed.
+	Stale    bool
+	FetchErr string
+	// Missing lists the sections the flags asked for that the snapshot a
+	// stale report was built from does not hold.
+	Missing []string
+}
+
+// Sections a snapshot may lack, named after their JSON keys.
+const (
+	sectionResolved    = "resolved_incidents"
+	sectionMaintenance = "scheduled_maintenances"
+)
+
+func (r report) missing(section string) bool {
+	return containsString(r.Missing, section)
+}
+
+// reportData is what a status page returned before any filtering. Snapshots
+// store it so that a saved report can be reshaped for whatever flags are
+// passed when it is loaded.
+type reportData struct {
+	StatusPage   string
+	FetchedAt    time.Time
+	Page         page
+	Status       pageStatus
+	Components   []component
+	Active       []incident
+	Maintenances []maintenance
+	Resolved     []incident
+	// Window is the range Resolved was drawn from.
+	Window timeWindow
+	// HasResolved and HasMaintenance record whether those sections were
+	// fetched at all, so an empty list is not mistaken for "none".
+	HasResolved    bool
+	HasMaintenance bool
 }
 
 func buildReport(ctx context.Context, client *statusClient, cfg config) (report, error) {
-	comps, err := client.Components(ctx)
+	data, err := fetchReportData(ctx, client, cfg)
 	if err != nil {
 		return report{}, err
 	}
+	return newReport(data, cfg)
+}
+
+// fetchReportData fetches everything cfg needs from the status page.
+// Maintenance comes with summary.json for free and is always kept; resolved
+// incidents cost extra requests and are only fetched when asked for.
+func fetchReportData(ctx context.Context, client *statusClient, cfg config) (reportData, error) {
+	includeResolved := cfg.showResolved || cfg.structured()
+	includeMaintenance := cfg.showMaintenance || cfg.structured()
+
+	d := reportData{StatusPage: client.siteURL, FetchedAt: time.Now()}
+
+	g, gctx := newFetchGroup(ctx)
 
-	r := report{
-		Components: filterComponents(comps),
+	g.Go(func() error {
+		summary, err := client.Summary(gctx)
+		if err == nil {
+			d.Page = summary.Page
+			d.Status = summary.Status
+			d.Components = summary.Components
+			d.Active = summary.Incidents
+			d.Maintenances = summary.ScheduledMaintenances
+			d.HasMaintenance = true
+			return nil
+		}
+		if gctx.Err() != nil {
+			return err
+		}
+
+		// summary.json is unavailable; fall back to the per-endpoint calls.
+		var inProgress, upcoming []maintenance
+		fg, fctx := newFetchGroup(gctx)
+		fg.Go(func() (err error) {
+			d.Components, err = client.Components(fctx)
+			return err
+		})
+		// Active incidents are always fetched to mark affected components.
+		fg.Go(func() (err error) {
+			d.Active, err = client.ActiveIncidents(fctx)
+			return err
+		})
+		if includeMaintenance {
+			fg.Go(func() (err error) {
+				inProgress, err = client.ActiveMaintenances(fctx)
+				return err
+			})
+			fg.Go(func() (err error) {
+				upcoming, err = client.UpcomingMaintenances(fctx)
+				return err
+			})
+		}
+		if err := fg.Wait(); err != nil {
+			return err
+		}
+		d.Maintenances = append(inProgress, upcoming...)
+		d.HasMaintenance = includeMaintenance
+		return nil
+	})
+
+	if includeResolved {
+		g.Go(func() (err error) {
+			d.Resolved, err = client.ResolvedIncidents(gctx, cfg.window)
+			return err
+		})
 	}
 
-	if len(r.Components) == 0 {
-		return report{}, fmt.Errorf("github status returned no components")
+	if err := g.Wait(); err != nil {
+		return reportData{}, err
+	}
+	if includeResolved {
+		d.Window = cfg.window
+		d.HasResolved = true
 	}
+	return d, nil
+}
+
+// newReport applies cfg's filters, ordering and sections to d. Sections cfg
+// asks for that d does not hold are listed in the report's Missing field.
+func newReport(d reportData, cfg config) (report, error) {
+	includeResolved := cfg.showResolved || cfg.structured()
+	includeMaintenance := cfg.showMaintenance || cfg.structured()
+
+	r := report{StatusPage: d.StatusPage, FetchedAt: d.FetchedAt, Page: d.Page, Status: d.Status}
 
-	includeActive := cfg.showDetails || cfg.output == outputJSON
-	includeResolved := cfg.showResolved || cfg.output == outputJSON
+	r.Components = sortComponents(filterComponents(d.Components, cfg.filter), cfg.sortBy)
 
-	if includeActive {
-		active, err := client.ActiveIncidents(ctx)
-		if err != nil {
-			return report{}, err
+	if len(r.Components) == 0 {
+		if !cfg.filter.empty() {
+			return report{}, &noMatchError{StatusPage: r.StatusPage}
 		}
-		r.Active = sortIncidents(active)
+		return report{}, fmt.Errorf("%s returned no components", r.StatusPage)
 	}
 
+	r.Tree = buildComponentTree(d.Components, r.Components, cfg.sortBy)
+
+	r.Active = sortIncidents(filterIncidents(d.Active, r.Components, cfg.filter))
+
 	if includeResolved {
-		resolved, err := client.RecentResolvedIncidents(ctx, resolvedLookback)
-		if err != nil {
-			return report{}, err
+		if d.HasResolved {
+			r.Window = cfg.window.intersect(d.Window)
+			var resolved []incident
+			for _, inc := range d.Resolved {
+				if t := incidentTime(inc); t.IsZero() || r.Window.contains(t) {
+					resolved = append(resolved, inc)
+				}
+			}
+			r.Resolved = sortIncidents(filterIncidents(resolved, r.Components, cfg.filter))
+		} else {
+			r.Missing = append(r.Missing, sectionResolved)
+		}
+	}
+
+	if includeMaintenance {
+		if d.HasMaintenance {
+			r.Maintenances = sortMaintenances(filterMaintenances(d.Maintenances, r.Components, cfg.filter))
+		} else {
+			r.Missing = append(r.Missing, sectionMaintenance)
 		}
-		r.Resolved = sortIncidents(resolved)
 	}
 
 	return r, nil
 }
 
-func filterComponents(components []component) []component {
+// noMatchError reports that the --component/--exclude filters left no
+// components. Unlike a failed fetch, polling again will not fix it.
+type noMatchError struct {
+	StatusPage string
+}
+
+func (e *noMatchError) Error() string {
+	return fmt.Sprintf("no components on %s match the --component/--exclude filters", e.StatusPage)
+}
+
+// fetchGroup runs independent fetches concurrently and cancels the rest as
+// soon as one of them fails.
+type fetchGroup struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+	once   sync.Once
+	err    error
+}
+
+func newFetchGroup(ctx context.Context) (*fetchGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &fetchGroup{cancel: cancel}, ctx
+}
+
+func (g *fetchGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until every fetch has returned and reports the first error.
+func (g *fetchGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// providerReport is the outcome of querying one status page as part of an
+// aggregate run. Err is set instead of failing the whole run when the page
+// could not be fetched.
+type providerReport struct {
+	report
+	Err error
+}
+
+// buildProviderReports queries every client concurrently and returns the
+// results in the same order as clients.
+func buildProviderReports(ctx context.Context, clients []*statusClient, snapshots *snapshotStore, cfg config) []providerReport {
+	results := make([]providerReport, len(clients))
+
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rep, err := fetchReport(ctx, client, snapshots, cfg)
+			if err != nil {
+				results[i] = providerReport{report: report{StatusPage: client.siteURL}, Err: err}
+				return
+			}
+			results[i] = providerReport{report: rep}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// componentFilter selects components by case-insensitive name or glob
+// pattern. The zero value selects every component.
+type componentFilter struct {
+	include []string
+	exclude []string
+}
+
+func (f componentFilter) empty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+func (f componentFilter) validate() error {
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := path.Match(normalizeName(pattern), ""); err != nil {
+			return fmt.Errorf("invalid component pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// selects reports whether a component known by any of names passes the
+// filter. A component is matched by its own name or its group's name.
+func (f componentFilter) selects(names ...string) bool {
+	if matchesAny(f.exclude, names) {
+		return false
+	}
+	return len(f.include) == 0 || matchesAny(f.include, names)
+}
+
+func matchesAny(patterns []string, names []string) bool {
+	for _, pattern := range patterns {
+		pattern = normalizeName(pattern)
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			name = normalizeName(name)
+			if pattern == name {
+				return true
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// componentGroups finds the group a component belongs to, so a filter can
+// match it by its group's name.
+type componentGroups struct {
+	// names maps group IDs to group names; parents maps component IDs to
+	// group IDs for components, such as those in incident updates, that
+	// carry no group_id of their own.
+	names   map[string]string
+	parents map[string]string
+}
+
+func newComponentGroups(components []component) componentGroups {
+	g := componentGroups{names: make(map[string]string), parents: make(map[string]string)}
+	for _, comp := range components {
+		if comp.Group && comp.ID != "" {
+			g.names[comp.ID] = comp.Name
+		}
+		if comp.ID != "" && comp.GroupID != "" {
+			g.parents[comp.ID] = comp.GroupID
+		}
+	}
+	return g
+}
+
+// of returns the name of comp's group, or "" if it has none or the group is
+// unknown.
+func (g componentGroups) of(comp component) string {
+	groupID := comp.GroupID
+	if groupID == "" {
+		groupID = g.parents[comp.ID]
+	}
+	return g.names[groupID]
+}
+
+func filterComponents(components []component, filter componentFilter) []component {
+	groups := newComponentGroups(components)
+
 	out := make([]component, 0, len(components))
 	for _, comp := range components {
 		if comp.Group {
@@ -58,6 +362,9 @@ func filterComponents(components []component) []component {
 		if strings.EqualFold(comp.Name, referenceComponent) {
 			continue
 		}
+		if !filter.selects(comp.Name, groups.of(comp)) {
+			continue
+		}
 		out = append(out, comp)
 	}
 
@@ -68,6 +375,170 @@ func filterComponents(components []component) []component {
 	return out
 }
 
+// filterIncidents keeps incidents that affect at least one of the selected
+// components. Incidents that name no components are kept unless the filter
+// asks for specific components.
+func filterIncidents(incidents []incident, selected []component, filter componentFilter) []incident {
+	if filter.empty() {
+		return incidents
+	}
+
+	set := newComponentSet(selected)
+	out := make([]incident, 0, len(incidents))
+	for _, inc := range incidents {
+		if set.affectedBy(inc, filter) {
+			out = append(out, inc)
+		}
+	}
+	return out
+}
+
+func filterMaintenances(maintenances []maintenance, selected []component, filter componentFilter) []maintenance {
+	if filter.empty() {
+		return maintenances
+	}
+
+	set := newComponentSet(selected)
+	out := make([]maintenance, 0, len(maintenances))
+	for _, m := range maintenances {
+		if set.affectedBy(m.incident, filter) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// componentSet indexes components by ID and normalized name.
+type componentSet struct {
+	ids   map[string]struct{}
+	names map[string]struct{}
+}
+
+func newComponentSet(components []component) componentSet {
+	set := componentSet{
+		ids:   make(map[string]struct{}, len(components)),
+		names: make(map[string]struct{}, len(components)),
+	}
+	for _, comp := range components {
+		if comp.ID != "" {
+			set.ids[comp.ID] = struct{}{}
+		}
+		set.names[normalizeName(comp.Name)] = struct{}{}
+	}
+	return set
+}
+
+func (s componentSet) contains(comp component) bool {
+	if _, ok := s.ids[comp.ID]; ok && comp.ID != "" {
+		return true
+	}
+	_, ok := s.names[normalizeName(comp.Name)]
+	return ok
+}
+
+func (s componentSet) affectedBy(inc incident, filter componentFilter) bool {
+	comps := incidentComponents(inc)
+	if len(comps) == 0 {
+		return len(filter.include) == 0
+	}
+	for _, comp := range comps {
+		if s.contains(comp) {
+			return true
+		}
+	}
+	return false
+}
+
+// openIncidentCounts counts the open incidents touching each component.
+type openIncidentCounts struct {
+	ids   map[string]int
+	names map[string]int
+}
+
+func countOpenIncidents(active []incident) openIncidentCounts {
+	counts := openIncidentCounts{ids: make(map[string]int), names: make(map[string]int)}
+	for _, inc := range active {
+		if incidentClosed(inc.Status) {
+			continue
+		}
+		for _, comp := range incidentComponents(inc) {
+			if comp.ID != "" {
+				counts.ids[comp.ID]++
+			} else {
+				counts.names[normalizeName(comp.Name)]++
+			}
+		}
+	}
+	return counts
+}
+
+func (c openIncidentCounts) of(comp component) int {
+	n := c.names[normalizeName(comp.Name)]
+	if comp.ID != "" {
+		n += c.ids[comp.ID]
+	}
+	return n
+}
+
+// sortComponents orders components alphabetically, or by their status page
+// position when order is sortByPosition.
+func sortComponents(components []component, order string) []component {
+	out := make([]component, len(components))
+	copy(out, components)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if order == sortByPosition && out[i].Position != out[j].Position {
+			return out[i].Position < out[j].Position
+		}
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+
+	return out
+}
+
+// componentNode is a top-level entry in the component hierarchy: either a
+// group with its member components or a component that belongs to no group.
+type componentNode struct {
+	component
+	Children []component
+}
+
+// buildComponentTree nests leaves under the groups found in all. Groups left
+// without any leaves are dropped, and leaves whose group is unknown are kept
+// at the top level.
+func buildComponentTree(all []component, leaves []component, order string) []componentNode {
+	groups := make(map[string]component)
+	for _, comp := range all {
+		if comp.Group && comp.ID != "" {
+			groups[comp.ID] = comp
+		}
+	}
+
+	children := make(map[string][]component)
+	var roots []component
+	for _, leaf := range leaves {
+		if _, ok := groups[leaf.GroupID]; ok && leaf.GroupID != "" {
+			children[leaf.GroupID] = append(children[leaf.GroupID], leaf)
+			continue
+		}
+		roots = append(roots, leaf)
+	}
+	for id := range children {
+		roots = append(roots, groups[id])
+	}
+
+	nodes := make([]componentNode, 0, len(roots))
+	for _, root := range sortComponents(roots, order) {
+		node := componentNode{component: root}
+		if root.Group {
+			node.Children = sortComponents(children[root.ID], order)
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
 func sortIncidents(incidents []incident) []incident {
 	out := make([]incident, len(incidents))
 	copy(out, incidents)
@@ -88,6 +559,31 @@ func sortIncidents(incidents []incident) []incident {
 	return out
 }
 
+func sortMaintenances(maintenances []maintenance) []maintenance {
+	out := make([]maintenance, 0, len(maintenances))
+	seen := make(map[string]struct{})
+	for _, m := range maintenances {
+		if m.ID != "" {
+			if _, found := seen[m.ID]; found {
+				continue
+			}
+			seen[m.ID] = struct{}{}
+		}
+		out = append(out, m)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		ti, _ := parseTime(out[i].ScheduledFor)
+		tj, _ := parseTime(out[j].ScheduledFor)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+
+	return out
+}
+
 func impactOrder(impact string) int {
 	switch strings.ToLower(strings.TrimSpace(impact)) {
 	case "critical":
@@ -103,6 +599,44 @@ func impactOrder(impact string) int {
 	}
 }
 
+// pageTitle names the status page being reported on, preferring the name
+// Statuspage reports and falling back to the site's host.
+func pageTitle(r report) string {
+	if name := strings.TrimSpace(r.Page.Name); name != "" {
+		return name
+	}
+	if r.StatusPage == "" || r.StatusPage == statusSiteURL {
+		return "GitHub"
+	}
+	if u, err := url.Parse(r.StatusPage); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return r.StatusPage
+}
+
+// formatAge renders d as a short human duration such as "45m" or "2d 3h".
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
 func formatTimestamp(raw string) string {
 	if t, ok := parseTime(raw); ok {
 		return t.Local().Format("Jan 02 15:04")
@@ -110,6 +644,19 @@ func formatTimestamp(raw string) string {
 	return raw
 }
 
+func formatWindow(start, end string) string {
+	switch {
+	case start == "" && end == "":
+		return ""
+	case end == "":
+		return "from " + formatTimestamp(start)
+	case start == "":
+		return "until " + formatTimestamp(end)
+	default:
+		return formatTimestamp(start) + " - " + formatTimestamp(end)
+	}
+}
+
 func summarizeBody(body string) string {
 	return strings.Join(strings.Fields(body), " ")
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// snapshotStore keeps the data behind the last successful report for each
+// status page so a report can be shown when the network is unavailable.
+type snapshotStore struct {
+	dir string
+}
+
+func newSnapshotStore(dir string) *snapshotStore {
+	return &snapshotStore{dir: dir}
+}
+
+// defaultSnapshotDir returns the gh-down snapshot directory under the user
+// cache dir.
+func defaultSnapshotDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gh-down", "snapshots"), nil
+}
+
+func (s *snapshotStore) path(statusPage string) string {
+	return keyedPath(s.dir, statusPage, ".json")
+}
+
+func (s *snapshotStore) save(d reportData) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(d.StatusPage), data)
+}
+
+// load returns the saved data for statusPage.
+func (s *snapshotStore) load(statusPage string) (reportData, error) {
+	if s == nil {
+		return reportData{}, fmt.Errorf("no saved snapshot for %s", statusPage)
+	}
+	data, err := os.ReadFile(s.path(statusPage))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return reportData{}, fmt.Errorf("no saved snapshot for %s; run gh down once while online", statusPage)
+		}
+		return reportData{}, fmt.Errorf("read snapshot: %w", err)
+	}
+	var d reportData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return reportData{}, fmt.Errorf("read snapshot: %w", err)
+	}
+	d.StatusPage = statusPage
+	return d, nil
+}
+
+// loadReport shapes the saved data for statusPage with cfg and marks the
+// result as stale.
+func (s *snapshotStore) loadReport(statusPage string, cfg config) (report, error) {
+	d, err := s.load(statusPage)
+	if err != nil {
+		return report{}, err
+	}
+	r, err := newReport(d, cfg)
+	if err != nil {
+		return report{}, err
+	}
+	r.Stale = true
+	return r, nil
+}
+
+// fetchReport builds a live report and records the unfiltered data behind it
+// as the latest snapshot. In offline mode, or when the status page cannot be
+// reached at all, it builds the report from the last snapshot instead.
+func fetchReport(ctx context.Context, client *statusClient, snapshots *snapshotStore, cfg config) (report, error) {
+	if cfg.offline {
+		return snapshots.loadReport(client.siteURL, cfg)
+	}
+
+	d, err := fetchReportData(ctx, client, cfg)
+	if err == nil {
+		// A failed save only costs us the offline fallback next time.
+		_ = snapshots.save(d)
+		return newReport(d, cfg)
+	}
+
+	if !networkError(err) {
+		return report{}, err
+	}
+	stale, loadErr := snapshots.loadReport(client.siteURL, cfg)
+	if loadErr != nil {
+		return report{}, err
+	}
+	stale.FetchErr = err.Error()
+	return stale, nil
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// unattributed names the bucket for incidents that list no components.
+const unattributed = "(no component listed)"
+
+// componentStats summarizes the incidents that affected one component in a
+// window. Impacted time is the union of the component's incident spans
+// clipped to the window, so overlapping incidents are not counted twice;
+// incidents with impact "none" and maintenance do not count as impacted.
+type componentStats struct {
+	Name         string
+	Incidents    int
+	ByImpact     map[string]int
+	Impacted     time.Duration
+	Availability float64
+	MTTR         time.Duration
+	Longest      incidentTimeline
+
+	resolved  int
+	repairSum time.Duration
+	spans     []span
+}
+
+type span struct {
+	start, end time.Time
+}
+
+// statsReport is the per-component breakdown plus a total across all
+// components.
+type statsReport struct {
+	Window     timeWindow
+	Length     time.Duration
+	Components []componentStats
+	Total      componentStats
+	// RefreshErr is set when the stats come from history that could not be
+	// refreshed from the status page.
+	RefreshErr error
+}
+
+// computeStats breaks timelines down per component. Every component in
+// components that the filter selects gets a row, so one without incidents
+// reports full availability; components named only by incidents get a row
+// too. The total covers every timeline.
+func computeStats(timelines []incidentTimeline, components []component, window timeWindow, filter componentFilter, now time.Time) statsReport {
+	end := window.Until
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+	rep := statsReport{Window: window, Length: end.Sub(window.Since), Total: componentStats{Name: "All components"}}
+
+	byName := make(map[string]*componentStats)
+	var order []string
+	row := func(name string) *componentStats {
+		key := normalizeName(name)
+		stats, ok := byName[key]
+		if !ok {
+			stats = &componentStats{Name: name}
+			byName[key] = stats
+			order = append(order, key)
+		}
+		return stats
+	}
+	for _, comp := range filterComponents(components, filter) {
+		row(comp.Name)
+	}
+
+	groups := newComponentGroups(components)
+	for _, tl := range timelines {
+		comps := incidentComponents(tl.Incident)
+		if len(comps) == 0 {
+			comps = []component{{Name: unattributed}}
+		}
+		seen := make(map[string]struct{}, len(comps))
+		for _, comp := range comps {
+			key := normalizeName(comp.Name)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			if comp.Name != unattributed && !filter.selects(comp.Name, groups.of(comp)) {
+				continue
+			}
+
+			row(comp.Name).add(tl, window.Since, end)
+		}
+		rep.Total.add(tl, window.Since, end)
+	}
+
+	for _, key := range order {
+		stats := byName[key]
+		stats.finish(rep.Length)
+		rep.Components = append(rep.Components, *stats)
+	}
+	rep.Total.finish(rep.Length)
+
+	sort.SliceStable(rep.Components, func(i, j int) bool {
+		a, b := rep.Components[i], rep.Components[j]
+		if a.Impacted != b.Impacted {
+			return a.Impacted > b.Impacted
+		}
+		if a.Incidents != b.Incidents {
+			return a.Incidents > b.Incidents
+		}
+		return a.Name < b.Name
+	})
+	return rep
+}
+
+func (s *componentStats) add(tl incidentTimeline, since, until time.Time) {
+	s.Incidents++
+	if s.ByImpact == nil {
+		s.ByImpact = make(map[string]int)
+	}
+	impact := strings.ToLower(strings.TrimSpace(tl.Incident.Impact))
+	if impact == "" {
+		impact = "none"
+	}
+	s.ByImpact[impact]++
+
+	if !tl.Ongoing {
+		s.resolved++
+		s.repairSum += tl.Duration()
+	}
+	if tl.Duration() > s.Longest.Duration() {
+		s.Longest = tl
+	}
+
+	if impact == "none" || impact == "maintenance" {
+		return
+	}
+	start, end := tl.Start, tl.End
+	if start.Before(since) {
+		start = since
+	}
+	if end.After(until) {
+		end = until
+	}
+	if end.After(start) {
+		s.spans = append(s.spans, span{start, end})
+	}
+}
+
+func (s *componentStats) finish(length time.Duration) {
+	sort.Slice(s.spans, func(i, j int) bool { return s.spans[i].start.Before(s.spans[j].start) })
+	var cur span
+	for i, sp := range s.spans {
+		switch {
+		case i == 0:
+			cur = sp
+		case !sp.start.After(cur.end):
+			if sp.end.After(cur.end) {
+				cur.end = sp.end
+			}
+		default:
+			s.Impacted += cur.end.Sub(cur.start)
+			cur = sp
+		}
+	}
+	if len(s.spans) > 0 {
+		s.Impacted += cur.end.Sub(cur.start)
+	}
+	s.spans = nil
+
+	s.Availability = 100
+	if length > 0 {
+		s.Availability = 100 * (1 - float64(s.Impacted)/float64(length))
+	}
+	if s.resolved > 0 {
+		s.MTTR = s.repairSum / time.Duration(s.resolved)
+	}
+}
+
+// impactKeys returns the impacts present in counts, most severe first.
+func impactKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for impact := range counts {
+		keys = append(keys, impact)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if oi, oj := impactOrder(keys[i]), impactOrder(keys[j]); oi != oj {
+			return oi < oj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func formatImpactCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, impact := range impactKeys(counts) {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[impact], impact))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatOptionalAge(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return formatAge(d)
+}
+
+func renderStatsText(w io.Writer, rep statsReport, now time.Time, st style) error {
+	printRefreshWarning(w, rep.RefreshErr, st)
+	fmt.Fprintf(w, "Reliability %s (%s)\n\n", rep.Window.describe(now), formatAge(rep.Length))
+	if rep.Total.Incidents == 0 {
+		fmt.Fprintln(w, "No incidents recorded.")
+		if len(rep.Components) == 0 {
+			return nil
+		}
+		fmt.Fprintln(w)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tINCIDENTS\tBY IMPACT\tIMPACTED\tAVAILABILITY\tMTTR\tLONGEST")
+	for _, stats := range append(rep.Components, rep.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.3f%%\t%s\t%s\n",
+			stats.Name,
+			stats.Incidents,
+			formatImpactCounts(stats.ByImpact),
+			formatOptionalAge(stats.Impacted),
+			stats.Availability,
+			formatOptionalAge(stats.MTTR),
+			formatOptionalAge(stats.Longest.Duration()),
+		)
+	}
+	return tw.Flush()
+}
+
+func renderStatsJSON(w io.Writer, statusPage string, rep statsReport) error {
+	payload := jsonStats{
+		StatusPage:    statusPage,
+		Stale:         rep.RefreshErr != nil,
+		Since:         rep.Window.Since.UTC().Format(time.RFC3339),
+		PeriodSeconds: int64(rep.Length / time.Second),
+		Components:    make([]jsonComponentStats, 0, len(rep.Components)),
+		Total:         buildJSONComponentStats(rep.Total),
+	}
+	if rep.RefreshErr != nil {
+		payload.RefreshError = rep.RefreshErr.Error()
+	}
+	if !rep.Window.Until.IsZero() {
+		payload.Until = rep.Window.Until.UTC().Format(time.RFC3339)
+	}
+	for _, stats := range rep.Components {
+		payload.Components = append(payload.Components, buildJSONComponentStats(stats))
+	}
+	return encodeJSON(w, payload)
+}
+
+func buildJSONComponentStats(stats componentStats) jsonComponentStats {
+	result := jsonComponentStats{
+		Name:                stats.Name,
+		Incidents:           stats.Incidents,
+		IncidentsByImpact:   stats.ByImpact,
+		ImpactedSeconds:     int64(stats.Impacted / time.Second),
+		AvailabilityPercent: stats.Availability,
+		MTTRSeconds:         int64(stats.MTTR / time.Second),
+	}
+	if result.IncidentsByImpact == nil {
+		result.IncidentsByImpact = map[string]int{}
+	}
+	if stats.Longest.Incident.ID != "" {
+		result.LongestIncident = &jsonLongestIncident{
+			ID:              stats.Longest.Incident.ID,
+			Name:            stats.Longest.Incident.Name,
+			DurationSeconds: int64(stats.Longest.Duration() / time.Second),
+		}
+	}
+	return result
+}
+
+type jsonStats struct {
+	StatusPage    string               `json:"status_page"`
+	Stale         bool                 `json:"stale"`
+	RefreshError  string               `json:"refresh_error,omitempty"`
+	Since         string               `json:"since"`
+	Until         string               `json:"until,omitempty"`
+	PeriodSeconds int64                `json:"period_seconds"`
+	Components    []jsonComponentStats `json:"components"`
+	Total         jsonComponentStats   `json:"total"`
+}
+
+type jsonComponentStats struct {
+	Name                string               `json:"name"`
+	Incidents           int                  `json:"incidents"`
+	IncidentsByImpact   map[string]int       `json:"incidents_by_impact"`
+	ImpactedSeconds     int64                `json:"impacted_seconds"`
+	AvailabilityPercent float64              `json:"availability_percent"`
+	MTTRSeconds         int64                `json:"mttr_seconds"`
+	LongestIncident     *jsonLongestIncident `json:"longest_incident,omitempty"`
+}
+
+type jsonLongestIncident struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
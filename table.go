@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/cli/go-gh/v2/pkg/text"
+)
+
+// terminalWidth returns the width to lay output out in, or 80 when stdout
+// is not a terminal.
+func terminalWidth(t term.Term) int {
+	if t.IsTerminalOutput() {
+		if w, _, err := t.Size(); err == nil && w > 0 {
+			return w
+		}
+	}
+	return 80
+}
+
+// renderTable prints the report as a components table followed by an
+// incidents table. On a terminal the columns are aligned to width and times
+// are relative; otherwise rows are tab-separated with RFC 3339 times and no
+// headers, like gh's own tables, and a stale report is flagged on warn
+// instead.
+func renderTable(w, warn io.Writer, r report, cfg config, tty bool, width int, now time.Time) error {
+	if tty {
+		fmt.Fprintf(w, "%s Service Status - %s (local time)\n", pageTitle(r), reportTime(r).Local().Format("Jan 02 15:04"))
+		if r.Stale {
+			fmt.Fprintf(w, "%sShowing saved snapshot from %s ago; live status was not fetched.\n", cfg.style.warning(), formatAge(now.Sub(reportTime(r))))
+		}
+		if cfg.showResolved && r.missing(sectionResolved) {
+			fmt.Fprintln(w, resolvedEmptyMessage(r))
+		}
+		fmt.Fprintln(w)
+	} else if r.Stale {
+		fmt.Fprintf(warn, "warning: showing saved snapshot of %s from %s ago; live status was not fetched\n", statusPageURL(r), formatAge(now.Sub(reportTime(r))))
+		if r.FetchErr != "" {
+			fmt.Fprintf(warn, "  %s\n", r.FetchErr)
+		}
+	}
+
+	open := countOpenIncidents(r.Active)
+	tp := tableprinter.New(w, tty, width)
+	tp.AddHeader([]string{"COMPONENT", "STATUS", "OPEN INCIDENTS", "UPDATED"})
+	addRow := func(indent string, comp component) {
+		name := comp.Name
+		if tty {
+			name = indent + name
+		}
+		tp.AddField(name)
+		tp.AddField(formatStatus(comp.Status), tableprinter.WithColor(statusColor(cfg.style, comp.Status)))
+		tp.AddField(strconv.Itoa(open.of(comp)))
+		tp.AddField(tableTime(comp.UpdatedAt, tty, now))
+		tp.EndRow()
+	}
+	if cfg.flat || len(r.Tree) == 0 {
+		for _, comp := range r.Components {
+			addRow("", comp)
+		}
+	} else {
+		for _, node := range r.Tree {
+			addRow("", node.component)
+			for _, child := range node.Children {
+				addRow("  ", child)
+			}
+		}
+	}
+	if err := tp.Render(); err != nil {
+		return err
+	}
+
+	incidents := r.Active
+	if cfg.showResolved {
+		incidents = append(append([]incident{}, r.Active...), r.Resolved...)
+	}
+	if len(incidents) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tp = tableprinter.New(w, tty, width)
+	tp.AddHeader([]string{"ID", "IMPACT", "STATUS", "STARTED", "DURATION", "NAME"})
+	for _, inc := range incidents {
+		tl := buildTimeline(inc, now)
+		started := ""
+		if !tl.Start.IsZero() {
+			started = tableTime(tl.Start.Format(time.RFC3339), tty, now)
+		}
+		duration := formatAge(tl.Duration())
+		if !tty {
+			duration = strconv.FormatInt(int64(tl.Duration()/time.Second), 10)
+		} else if tl.Ongoing {
+			duration += " so far"
+		}
+
+		tp.AddField(inc.ID)
+		tp.AddField(strings.ToLower(strings.TrimSpace(inc.Impact)))
+		tp.AddField(formatStatus(inc.Status), tableprinter.WithColor(statusColor(cfg.style, inc.Status)))
+		tp.AddField(started)
+		tp.AddField(duration)
+		tp.AddField(inc.Name)
+		tp.EndRow()
+	}
+	return tp.Render()
+}
+
+// statusColor colors a status cell without upsetting column widths, which
+// tableprinter measures before applying the color.
+func statusColor(st style, status string) func(string) string {
+	return func(s string) string {
+		return st.paint(status, s)
+	}
+}
+
+// tableTime formats an API timestamp as "2 hours ago" on a terminal and as
+// RFC 3339 otherwise.
+func tableTime(raw string, tty bool, now time.Time) string {
+	t, ok := parseTime(raw)
+	if !ok {
+		return ""
+	}
+	if !tty {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return text.RelativeTimeAgo(now, t)
+}
+
+func renderProvidersTable(w, warn io.Writer, providers []providerReport, cfg config, tty bool, width int, now time.Time) error {
+	for i, p := range providers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if p.Err != nil {
+			fmt.Fprintf(w, "%s Service Status - unavailable\n  %v\n", pageTitle(p.report), p.Err)
+			continue
+		}
+		if err := renderTable(w, warn, p.report, cfg, tty, width, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
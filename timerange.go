@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultHistoryWindow = 7 * 24 * time.Hour
+
+// timeWindow is a closed time range. A zero Until means "now".
+type timeWindow struct {
+	Since time.Time
+	Until time.Time
+}
+
+func (w timeWindow) contains(t time.Time) bool {
+	if !w.Since.IsZero() && t.Before(w.Since) {
+		return false
+	}
+	if !w.Until.IsZero() && t.After(w.Until) {
+		return false
+	}
+	return true
+}
+
+// intersect returns the part of w that other also covers.
+func (w timeWindow) intersect(other timeWindow) timeWindow {
+	if other.Since.After(w.Since) {
+		w.Since = other.Since
+	}
+	if !other.Until.IsZero() && (w.Until.IsZero() || other.Until.Before(w.Until)) {
+		w.Until = other.Until
+	}
+	return w
+}
+
+// describe renders the window for messages such as "in the last 7 days".
+func (w timeWindow) describe(now time.Time) string {
+	switch {
+	case w.Since.IsZero() && w.Until.IsZero():
+		return "on record"
+	case w.Until.IsZero():
+		age := now.Sub(w.Since).Round(time.Hour)
+		if age > 0 && age%(24*time.Hour) == 0 {
+			days := int(age / (24 * time.Hour))
+			if days == 1 {
+				return "in the last day"
+			}
+			return fmt.Sprintf("in the last %d days", days)
+		}
+		return "since " + w.Since.Local().Format("Jan 02 2006 15:04")
+	case w.Since.IsZero():
+		return "before " + w.Until.Local().Format("Jan 02 2006 15:04")
+	default:
+		return fmt.Sprintf("between %s and %s", w.Since.Local().Format("Jan 02 2006 15:04"), w.Until.Local().Format("Jan 02 2006 15:04"))
+	}
+}
+
+// parseWindow parses --since and --until. An empty until means "now".
+func parseWindow(since, until string, now time.Time) (timeWindow, error) {
+	var w timeWindow
+	var err error
+	if strings.TrimSpace(since) != "" {
+		if w.Since, err = parseTimeBound(since, now); err != nil {
+			return w, fmt.Errorf("since: %w", err)
+		}
+	}
+	if strings.TrimSpace(until) != "" {
+		if w.Until, err = parseTimeBound(until, now); err != nil {
+			return w, fmt.Errorf("until: %w", err)
+		}
+	}
+	if !w.Since.IsZero() && !w.Until.IsZero() && w.Until.Before(w.Since) {
+		return w, fmt.Errorf("until must not be before since")
+	}
+	return w, nil
+}
+
+// parseTimeBound parses a point in time: "now", a look-back duration such
+// as "72h", "30d", "2h ago" or "2 days ago", a local date ("2026-09-01"), a local date and
+// time ("2026-09-01 14:30"), a local time of day today ("14:30") or an
+// RFC 3339 timestamp.
+func parseTimeBound(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	if strings.EqualFold(raw, "now") {
+		return now, nil
+	}
+
+	lookback := raw
+	if fields := strings.Fields(raw); len(fields) > 1 && strings.EqualFold(fields[len(fields)-1], "ago") {
+		lookback = strings.Join(fields[:len(fields)-1], " ")
+	}
+	if d, ok := parseLookback(lookback); ok {
+		return now.Add(-d), nil
+	}
+
+	if t, ok := parseTime(raw); ok {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			y, m, d := now.In(time.Local).Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: use \"now\", a duration like 72h, 30d or \"2 days ago\", a date like 2026-09-01, a date and time like \"2026-09-01 14:30\", a time of day like 14:30, or RFC 3339", raw)
+}
+
+// parseRange parses "<time>" or "<from>..<to>". A single time yields a
+// window whose bounds are equal.
+func parseRange(raw string, now time.Time) (timeWindow, error) {
+	from, to, isRange := strings.Cut(raw, "..")
+	if !isRange {
+		t, err := parseTimeBound(raw, now)
+		return timeWindow{Since: t, Until: t}, err
+	}
+	if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+		return timeWindow{}, fmt.Errorf("invalid range %q: expected <from>..<to>", raw)
+	}
+	return parseWindow(from, to, now)
+}
+
+// lookbackUnits are the units accepted after the number in look-backs such
+// as "30d", "2 days" or "1 week".
+var lookbackUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseLookback accepts Go durations ("1h30m") and a number followed by one
+// of lookbackUnits, with or without a space ("30d", "2 days").
+func parseLookback(raw string) (time.Duration, bool) {
+	raw = strings.ToLower(strings.Join(strings.Fields(raw), ""))
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return d, true
+	}
+	end := strings.IndexFunc(raw, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end <= 0 {
+		return 0, false
+	}
+	unit, ok := lookbackUnits[raw[end:]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(raw[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n * float64(unit)), true
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const defaultWaitTimeout = time.Hour
+
+// waitTargets are the component statuses accepted by --until, best first.
+var waitTargets = []string{"operational", "degraded_performance", "under_maintenance", "partial_outage", "major_outage"}
+
+type waitResult struct {
+	// Err is set when waiting cannot succeed, such as when the filters match
+	// no components.
+	Err     error
+	Reached bool
+	// Interrupted is set when the wait was cancelled, as by Ctrl+C, rather
+	// than timed out.
+	Interrupted bool
+	Target      string
+	Elapsed     time.Duration
+	Components  []component
+}
+
+// runWait polls until every selected component is at least as healthy as
+// cfg.until or ctx is done. Progress is written to progress; fetch errors
+// are reported there and retried on the next poll, but filters that match
+// no components end the wait at once.
+func runWait(ctx context.Context, client *statusClient, cfg config, progress io.Writer) waitResult {
+	start := time.Now()
+	result := waitResult{Target: cfg.until}
+
+	// The report only needs components; do not let --json pull incidents.
+	pollCfg := cfg
+	pollCfg.output = outputText
+
+	for {
+		rep, err := buildReport(ctx, client, pollCfg)
+		var noMatch *noMatchError
+		if errors.As(err, &noMatch) {
+			result.Err = err
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Fprintf(progress, "[%s] %v\n", time.Now().Local().Format("15:04:05"), err)
+		} else {
+			result.Components = rep.Components
+			pending := pendingComponents(rep.Components, cfg.until)
+			if len(pending) == 0 {
+				result.Reached = true
+				break
+			}
+			printWaitProgress(progress, pending, cfg.until, cfg.style)
+		}
+
+		if !sleepContext(ctx, cfg.interval) {
+			break
+		}
+	}
+
+	if !result.Reached && result.Err == nil && errors.Is(ctx.Err(), context.Canceled) {
+		result.Interrupted = true
+	}
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// pendingComponents returns the components that are less healthy than
+// target. Statuses of equal severity, such as under_maintenance and
+// degraded_performance, satisfy each other.
+func pendingComponents(components []component, target string) []component {
+	want := componentHealth(target)
+	var pending []component
+	for _, comp := range components {
+		if componentHealth(comp.Status) > want {
+			pending = append(pending, comp)
+		}
+	}
+	return pending
+}
+
+func printWaitProgress(w io.Writer, pending []component, target string, st style) {
+	parts := make([]string, 0, len(pending))
+	for _, comp := range pending {
+		parts = append(parts, fmt.Sprintf("%s%s - %s", st.icon(comp.Status), comp.Name, st.status(comp.Status)))
+	}
+	fmt.Fprintf(w, "[%s] Waiting for %d component(s) to reach %s: %s\n",
+		time.Now().Local().Format("15:04:05"), len(pending), st.status(target), strings.Join(parts, ", "))
+}
+
+func validWaitTarget(target string) bool {
+	for _, candidate := range waitTargets {
+		if target == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func renderWaitResult(w io.Writer, result waitResult, cfg config) error {
+	if cfg.output == outputJSON {
+		payload := jsonWaitResult{
+			Reached:        result.Reached,
+			Target:         result.Target,
+			ElapsedSeconds: int64(result.Elapsed.Round(time.Second) / time.Second),
+			Components:     make([]jsonComponent, 0, len(result.Components)),
+		}
+		for _, comp := range result.Components {
+			payload.Components = append(payload.Components, buildJSONComponent(comp))
+		}
+		return encodeJSON(w, payload)
+	}
+
+	if result.Reached {
+		fmt.Fprintf(w, "%sAll selected components are %s (waited %s).\n", cfg.style.icon("operational"), cfg.style.status(result.Target), formatAge(result.Elapsed))
+		return nil
+	}
+	fmt.Fprintf(w, "%sGave up after %s waiting for components to reach %s.\n", cfg.style.icon("major_outage"), formatAge(result.Elapsed), cfg.style.status(result.Target))
+	return nil
+}
+
+type jsonWaitResult struct {
+	Reached        bool            `json:"reached"`
+	Target         string          `json:"target"`
+	ElapsedSeconds int64           `json:"elapsed_seconds"`
+	Components     []jsonComponent `json:"components"`
+}
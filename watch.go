@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWatchInterval = 30 * time.Second
+	// maxWatchChanges bounds the change log kept under the live summary.
+	maxWatchChanges = 20
+)
+
+// change describes one difference between two successive reports.
+type change struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+}
+
+const (
+	changeComponent        = "component"
+	changeIncidentNew      = "incident_new"
+	changeIncidentUpdate   = "incident_update"
+	changeIncidentResolved = "incident_resolved"
+	changePollError        = "poll_error"
+)
+
+func (c change) String() string {
+	return fmt.Sprintf("[%s] %s", c.Time.Local().Format("15:04:05"), c.Text)
+}
+
+// runWatch polls the status page every cfg.interval until ctx is cancelled,
+// printing what changed between polls. On a terminal the current summary is
+// redrawn above a rolling change log; otherwise only change lines are
+// appended after an initial summary.
+func runWatch(ctx context.Context, client *statusClient, cfg config, w io.Writer, tty bool) error {
+	var (
+		prev    *report
+		history []change
+	)
+
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+		rep, err := buildReport(pollCtx, client, cfg)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// Change text is part of the JSON stream, so it keeps the default
+		// emoji and no color there.
+		st := cfg.style
+		if cfg.output == outputJSON {
+			st = style{}
+		}
+
+		var changes []change
+		switch {
+		case err != nil:
+			changes = []change{{Time: time.Now(), Kind: changePollError, Text: st.warning() + err.Error()}}
+		case prev != nil:
+			changes = diffReports(*prev, rep, time.Now(), st)
+		}
+
+		history = append(history, changes...)
+		if len(history) > maxWatchChanges {
+			history = history[len(history)-maxWatchChanges:]
+		}
+
+		if err == nil {
+			if werr := printWatch(w, rep, prev == nil, changes, history, cfg, tty); werr != nil {
+				return werr
+			}
+			prev = &rep
+		} else if werr := printChanges(w, changes, cfg); werr != nil {
+			return werr
+		}
+
+		timer := time.NewTimer(cfg.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func printWatch(w io.Writer, r report, first bool, changes, history []change, cfg config, tty bool) error {
+	if cfg.output == outputJSON {
+		if first {
+			return renderJSON(w, r, cfg)
+		}
+		return printChanges(w, changes, cfg)
+	}
+
+	if !tty {
+		if first {
+			renderText(w, r, cfg)
+			fmt.Fprintln(w)
+		}
+		return printChanges(w, changes, cfg)
+	}
+
+	// Clear the screen and redraw the summary with the recent changes below.
+	fmt.Fprint(w, "\033[H\033[2J")
+	renderText(w, r, cfg)
+	fmt.Fprintf(w, "\nWatching every %s - press Ctrl+C to stop.\n", cfg.interval)
+	if len(history) > 0 {
+		fmt.Fprintln(w, "\nRecent changes:")
+		for _, c := range history {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	return nil
+}
+
+func printChanges(w io.Writer, changes []change, cfg config) error {
+	if cfg.output == outputJSON {
+		enc := json.NewEncoder(w)
+		for _, c := range changes {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, c := range changes {
+		fmt.Fprintln(w, c)
+	}
+	return nil
+}
+
+// diffReports lists component status transitions, new incidents, new
+// incident updates and resolutions between prev and next, marked with st.
+func diffReports(prev, next report, now time.Time, st style) []change {
+	var changes []change
+
+	prevComponents := make(map[string]component, len(prev.Components))
+	for _, comp := range prev.Components {
+		prevComponents[componentKey(comp)] = comp
+	}
+	for _, comp := range next.Components {
+		old, ok := prevComponents[componentKey(comp)]
+		if !ok || strings.EqualFold(old.Status, comp.Status) {
+			continue
+		}
+		changes = append(changes, change{
+			Time:    now,
+			Kind:    changeComponent,
+			Subject: comp.Name,
+			Text:    fmt.Sprintf("%s%s: %s → %s", st.icon(comp.Status), comp.Name, st.status(old.Status), st.status(comp.Status)),
+		})
+	}
+
+	prevIncidents := make(map[string]incident, len(prev.Active))
+	for _, inc := range prev.Active {
+		prevIncidents[incidentKey(inc)] = inc
+	}
+	nextIncidents := make(map[string]struct{}, len(next.Active))
+	for _, inc := range next.Active {
+		nextIncidents[incidentKey(inc)] = struct{}{}
+
+		old, seen := prevIncidents[incidentKey(inc)]
+		if !seen {
+			text := fmt.Sprintf("%sNew incident: %s", st.icon(inc.Status), inc.Name)
+			if impact := formatStatus(inc.Impact); impact != "" && !strings.EqualFold(impact, "None") {
+				text += fmt.Sprintf(" (Impact: %s)", st.paint(inc.Impact, impact))
+			}
+			changes = append(changes, change{Time: now, Kind: changeIncidentNew, Subject: inc.Name, Text: text})
+		}
+
+		known := make(map[string]struct{}, len(old.IncidentUpdates))
+		for _, upd := range old.IncidentUpdates {
+			known[updateKey(upd)] = struct{}{}
+		}
+		// Statuspage lists updates newest first; report them in the order
+		// they happened.
+		for i := len(inc.IncidentUpdates) - 1; i >= 0; i-- {
+			upd := inc.IncidentUpdates[i]
+			if _, ok := known[updateKey(upd)]; ok {
+				continue
+			}
+			changes = append(changes, change{
+				Time:    now,
+				Kind:    changeIncidentUpdate,
+				Subject: inc.Name,
+				Text:    fmt.Sprintf("%s%s - %s: %s", st.icon(upd.Status), inc.Name, st.status(upd.Status), summarizeBody(upd.Body)),
+			})
+		}
+	}
+
+	for _, inc := range prev.Active {
+		if _, ok := nextIncidents[incidentKey(inc)]; ok {
+			continue
+		}
+		changes = append(changes, change{
+			Time:    now,
+			Kind:    changeIncidentResolved,
+			Subject: inc.Name,
+			Text:    fmt.Sprintf("%sResolved: %s", st.icon("resolved"), inc.Name),
+		})
+	}
+
+	return changes
+}
+
+func componentKey(comp component) string {
+	if comp.ID != "" {
+		return comp.ID
+	}
+	return normalizeName(comp.Name)
+}
+
+func incidentKey(inc incident) string {
+	if inc.ID != "" {
+		return inc.ID
+	}
+	return normalizeName(inc.Name)
+}
+
+func updateKey(upd incidentUpdate) string {
+	return upd.CreatedAt + "\x00" + upd.Status + "\x00" + upd.Body
+}